@@ -3,27 +3,81 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
-	"path/filepath"
 
+	"github.com/Isaac799/devtoolbox"
 	"github.com/Isaac799/devtoolbox/internal/site"
+	"github.com/Isaac799/devtoolbox/internal/strgen"
+	"github.com/Isaac799/devtoolbox/pkg/tmplcache"
 )
 
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+  devtoolbox [serve] [-public ./public] [-templates ./templates] [-reload]
+  devtoolbox gen -in schema.md -out ./app [-targets go,postgres,mysql,sqlite,hurl,openapi,typescript,jsonschema] [-templates ./templates] [-nullable pointer|generic] [-dialect postgres|sqlite] [-from old.md] [-migrations unix|sequence|goose|dbmate] [-seq n] [-depth n]
+  devtoolbox import -postgres dump.sql | -go ./pkg/domain`)
+}
+
+// assetsFS provides the embedded file system, unless a directory is
+// provided to override it. Overriding is useful during development
+// to see template changes without a rebuild.
+func assetsFS(embedded fs.FS, dir string) fs.FS {
+	if len(dir) == 0 {
+		return embedded
+	}
+	return os.DirFS(dir)
+}
+
 func main() {
-	b := flag.Bool("debug", false, "moves up 2 dir")
-	flag.Parse()
-	if b != nil && *b {
-		os.Chdir("..")
-		os.Chdir("..")
+	args := os.Args[1:]
+
+	cmd := "serve"
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		cmd, args = args[0], args[1:]
 	}
 
-	store := site.NewClientStore(100)
+	switch cmd {
+	case "serve":
+		serve(args)
+	case "gen":
+		os.Exit(gen(args))
+	case "import":
+		os.Exit(importSchema(args))
+	case "help":
+		usage()
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// serve runs the web ui
+func serve(args []string) {
+	var (
+		flags        = flag.NewFlagSet("serve", flag.ExitOnError)
+		publicDir    = flags.String("public", "", "directory to read public files from instead of the embedded ones")
+		templatesDir = flags.String("templates", "", "directory to read templates from instead of the embedded ones")
+		reload       = flags.Bool("reload", false, "parse templates on every request instead of once, to see edits without a restart")
+	)
+	flags.Parse(args)
+
+	cache := tmplcache.New(*reload)
+
+	public := assetsFS(devtoolbox.Public(), *publicDir)
+	strgen.UseTemplates(assetsFS(devtoolbox.Templates(), *templatesDir), cache)
+
+	store := site.NewClientStore(100, public, cache)
 
 	mux := http.NewServeMux()
 
-	script := http.FileServer(http.Dir(filepath.Join("public", "asset")))
+	asset, err := fs.Sub(public, "asset")
+	if err != nil {
+		log.Fatal(err)
+	}
+	script := http.FileServer(http.FS(asset))
 	mux.Handle("/public/asset/", http.StripPrefix("/public/asset/", script))
 
 	mux.HandleFunc("/child/{id}", func(w http.ResponseWriter, r *http.Request) {
@@ -53,7 +107,7 @@ func main() {
 	})
 
 	fmt.Println("running")
-	err := http.ListenAndServe(":8080", mux)
+	err = http.ListenAndServe(":8080", mux)
 	if err != nil {
 		log.Fatal(err)
 	}
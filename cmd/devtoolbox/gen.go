@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Isaac799/devtoolbox"
+	"github.com/Isaac799/devtoolbox/internal/strgen"
+	"github.com/Isaac799/devtoolbox/internal/strparse"
+	"github.com/Isaac799/devtoolbox/pkg/model"
+	"github.com/Isaac799/devtoolbox/pkg/tmplcache"
+)
+
+// generator is a target that can be generated from the command line
+type generator = func([]*model.Schema) (map[strgen.FileName]string, error)
+
+// generators are the targets by name, with options applied. Given the
+// schemas a database is at, postgres migrates it rather than setting it up.
+// Parsed files share no ids, so a rename between them is a drop and an add.
+func generators(goOpts strgen.GoOptions, migrationOpts strgen.MigrationOptions, from []*model.Schema) map[string]generator {
+	postgres := func(schemas []*model.Schema) (map[strgen.FileName]string, error) {
+		return strgen.PostgresSetup(schemas, migrationOpts)
+	}
+	if from != nil {
+		postgres = func(schemas []*model.Schema) (map[strgen.FileName]string, error) {
+			warnings, err := strgen.PostgresMigrateWarnings(from, schemas)
+			if err != nil {
+				return nil, err
+			}
+			for _, w := range warnings {
+				fmt.Fprintf(os.Stderr, "postgres: warning: %s\n", w)
+			}
+			return strgen.PostgresMigrate(from, schemas, migrationOpts)
+		}
+	}
+
+	return map[string]generator{
+		"go": func(schemas []*model.Schema) (map[strgen.FileName]string, error) {
+			return strgen.GoStructs(schemas, goOpts)
+		},
+		"postgres":   postgres,
+		"mysql":      strgen.MySQLSetup,
+		"sqlite":     strgen.SQLiteSetup,
+		"hurl":       strgen.HurlTests,
+		"openapi":    strgen.OpenAPI,
+		"typescript": strgen.TypeScript,
+		"jsonschema": strgen.JSONSchema,
+	}
+}
+
+var _goNullable = map[string]strgen.GoNullable{
+	"pointer": strgen.GoNullablePointer,
+	"generic": strgen.GoNullableGeneric,
+}
+
+var _goDialect = map[string]strgen.GoDialect{
+	"postgres": strgen.GoDialectPostgres,
+	"sqlite":   strgen.GoDialectSQLite,
+}
+
+var _migrationStyle = map[string]strgen.MigrationStyle{
+	"unix":     strgen.MigrationUnix,
+	"sequence": strgen.MigrationSequence,
+	"goose":    strgen.MigrationGoose,
+	"dbmate":   strgen.MigrationDbmate,
+}
+
+// gen generates files from a schema without the web ui, so it can be
+// used in ci or a makefile. It returns the exit code.
+func gen(args []string) int {
+	var (
+		flags   = flag.NewFlagSet("gen", flag.ContinueOnError)
+		in      = flags.String("in", "-", "schema file to read, - for stdin")
+		out     = flags.String("out", ".", "directory to write generated files to")
+		targets = flags.String("targets", "go,postgres,hurl", "csv of targets to generate")
+		tmplDir = flags.String("templates", "", "directory to read templates from instead of the embedded ones")
+		null    = flags.String("nullable", "pointer", "how go holds a nullable attribute: pointer or generic")
+		dialect = flags.String("dialect", "postgres", "sql dialect of the go store: postgres or sqlite")
+		fromIn  = flags.String("from", "", "schema file the database is at, to migrate postgres from it (renames are dropped and added)")
+		style   = flags.String("migrations", "unix", "how postgres migrations are named: unix, sequence, goose or dbmate")
+		seq     = flags.Int("seq", 1, "number of the migration when named by sequence")
+		depth   = flags.Int("depth", model.DefaultDepth, "how many references deep keys are flattened")
+	)
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	nullable, ok := _goNullable[strings.ToLower(*null)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown nullable: %s\n", *null)
+		return 2
+	}
+	goDialect, ok := _goDialect[strings.ToLower(*dialect)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown dialect: %s\n", *dialect)
+		return 2
+	}
+
+	migration, ok := _migrationStyle[strings.ToLower(*style)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown migrations: %s\n", *style)
+		return 2
+	}
+
+	if *depth < 1 {
+		fmt.Fprintf(os.Stderr, "depth must be at least 1: %d\n", *depth)
+		return 2
+	}
+	parseOpts := strparse.Options{Depth: *depth}
+
+	var from []*model.Schema
+	if len(*fromIn) > 0 {
+		schemas, code := parseSchemas(*fromIn, parseOpts)
+		if code != 0 {
+			return code
+		}
+		from = schemas
+	}
+	gens := generators(
+		strgen.GoOptions{Nullable: nullable, Dialect: goDialect},
+		strgen.MigrationOptions{Style: migration, Sequence: *seq},
+		from,
+	)
+
+	strgen.UseTemplates(assetsFS(devtoolbox.Templates(), *tmplDir), tmplcache.New(false))
+
+	names := make([]string, 0, len(gens))
+	for _, s := range strings.Split(*targets, ",") {
+		name := strings.ToLower(strings.TrimSpace(s))
+		if len(name) == 0 {
+			continue
+		}
+		if _, ok := gens[name]; !ok {
+			fmt.Fprintf(os.Stderr, "unknown target: %s\n", name)
+			return 2
+		}
+		if slices.Contains(names, name) {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		fmt.Fprintln(os.Stderr, "no targets")
+		return 2
+	}
+
+	schemas, code := parseSchemas(*in, parseOpts)
+	if code != 0 {
+		return code
+	}
+
+	for _, name := range names {
+		files, err := gens[name](schemas)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+			return 1
+		}
+		if err := writeFiles(*out, files); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// parseSchemas reads and parses a schema file, reporting its diagnostics.
+// It returns the exit code if there is a problem.
+func parseSchemas(name string, opts strparse.Options) ([]*model.Schema, int) {
+	b, err := readInput(name)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return nil, 1
+	}
+
+	result := strparse.ParseWith(string(b), opts)
+
+	for _, d := range result.Diagnostics {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", name, d.String())
+	}
+
+	hasErr := result.HasErr()
+	for _, sch := range result.Schemas {
+		if sch.HasErr() {
+			hasErr = true
+		}
+	}
+	if hasErr {
+		return nil, 1
+	}
+
+	if len(result.Schemas) == 0 {
+		fmt.Fprintln(os.Stderr, "no schemas found")
+		return nil, 1
+	}
+
+	return result.Schemas, 0
+}
+
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+// writeFiles writes each generated file under the out directory
+func writeFiles(out string, files map[strgen.FileName]string) error {
+	keys := make([]strgen.FileName, 0, len(files))
+	for k := range files {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		v := files[k]
+		dir := filepath.Join(out, k.Path())
+		name := filepath.Join(out, k.Full())
+
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, []byte(v), 0o644); err != nil {
+			return err
+		}
+		fmt.Println(name)
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMockSchemaShop = `# Shop
+
+## Item
+- id          as ++
+- name        as str  with required, 3..30`
+
+const testMockSchemaShopPriced = testMockSchemaShop + `
+- price       as int  with required`
+
+const testMockSchemaKitchen = `# Kitchen
+
+## Food
+- id          as ++
+
+## Ingredient
+- id          as ++
+
+## Recipe
+- @food               with required, primary
+- @ingredient         with required, primary
+
+# Restaurant
+
+## Order
+- id          as ++
+- @kitchen.recipe     with required`
+
+// testWrite writes a file in the dir, providing its path
+func testWrite(t *testing.T, dir, name, s string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(s), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// testWritten is what was written under the out dir, relative to it
+func testWritten(t *testing.T, out string) map[string]string {
+	t.Helper()
+	m := make(map[string]string)
+	err := filepath.WalkDir(out, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(out, p)
+		if err != nil {
+			return err
+		}
+		m[filepath.ToSlash(rel)] = string(b)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestGen(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		schema string
+		from   string
+		args   []string
+		code   int
+		// files are globs of what is written, to what each contains
+		files map[string]string
+	}{
+		{
+			name:   "default targets",
+			schema: testMockSchemaShop,
+			files: map[string]string{
+				"go.mod":                      "module",
+				"internal/shop/shop.go":       "type Item struct",
+				"migrations/*_setup.up.sql":   "CREATE TABLE shop.item (",
+				"migrations/*_setup.down.sql": "DROP TABLE",
+				"tests/shop.item.hurl":        "",
+			},
+		},
+		{
+			name:   "selected targets",
+			schema: testMockSchemaShop,
+			args:   []string{"-targets", " MySQL, sqlite,mysql "},
+			files: map[string]string{
+				"migrations/mysql/*.sql":  "CREATE DATABASE IF NOT EXISTS shop;",
+				"migrations/sqlite/*.sql": "CREATE TABLE",
+			},
+		},
+		{
+			name:   "api targets",
+			schema: testMockSchemaShop,
+			args:   []string{"-targets", "openapi,typescript,jsonschema"},
+			files: map[string]string{
+				"api/openapi.yaml":                 "",
+				"web/api/api.ts":                   "",
+				"jsonschema/shop/item.schema.json": "",
+			},
+		},
+		{
+			name:   "sequence",
+			schema: testMockSchemaShop,
+			args:   []string{"-targets", "postgres", "-migrations", "sequence", "-seq", "7"},
+			files: map[string]string{
+				"migrations/000007_setup.up.sql":   "CREATE TABLE shop.item (",
+				"migrations/000007_setup.down.sql": "",
+			},
+		},
+		{
+			name:   "from",
+			schema: testMockSchemaShopPriced,
+			from:   testMockSchemaShop,
+			args:   []string{"-targets", "postgres", "-migrations", "goose"},
+			files: map[string]string{
+				"migrations/*.sql": "ALTER TABLE shop.item ADD COLUMN price",
+			},
+		},
+		{
+			name:   "from renamed",
+			schema: strings.Replace(testMockSchemaShop, "- name ", "- title", 1),
+			from:   testMockSchemaShop,
+			args:   []string{"-targets", "postgres", "-migrations", "goose"},
+			files: map[string]string{
+				// parsed files share no ids, so the rename is not found
+				"migrations/*.sql": "ALTER TABLE shop.item DROP COLUMN name;",
+			},
+		},
+		{
+			name:   "depth",
+			schema: testMockSchemaKitchen,
+			args:   []string{"-targets", "postgres", "-depth", "2"},
+			files: map[string]string{
+				"migrations/*_setup.up.sql": "kitchen_recipe_food_id",
+			},
+		},
+		{
+			name:   "deeper than the default",
+			schema: testMockSchemaKitchen,
+			args:   []string{"-targets", "postgres"},
+			code:   1,
+		},
+		{
+			name:   "unknown target",
+			schema: testMockSchemaShop,
+			args:   []string{"-targets", "go,cobol"},
+			code:   2,
+		},
+		{
+			name:   "no targets",
+			schema: testMockSchemaShop,
+			args:   []string{"-targets", " , "},
+			code:   2,
+		},
+		{
+			name:   "bad flag",
+			schema: testMockSchemaShop,
+			args:   []string{"-bogus"},
+			code:   2,
+		},
+		{
+			name:   "unknown nullable",
+			schema: testMockSchemaShop,
+			args:   []string{"-nullable", "maybe"},
+			code:   2,
+		},
+		{
+			name:   "unknown dialect",
+			schema: testMockSchemaShop,
+			args:   []string{"-dialect", "oracle"},
+			code:   2,
+		},
+		{
+			name:   "unknown migrations",
+			schema: testMockSchemaShop,
+			args:   []string{"-migrations", "flyway"},
+			code:   2,
+		},
+		{
+			name:   "shallow depth",
+			schema: testMockSchemaShop,
+			args:   []string{"-depth", "0"},
+			code:   2,
+		},
+		{
+			name:   "schema errors",
+			schema: testMockSchemaShop + "\n- @missing",
+			code:   1,
+		},
+		{
+			name: "no schemas",
+			code: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				dir  = t.TempDir()
+				out  = t.TempDir()
+				args = []string{"-in", testWrite(t, dir, "schema.md", tc.schema), "-out", out}
+			)
+			if len(tc.from) > 0 {
+				args = append(args, "-from", testWrite(t, dir, "from.md", tc.from))
+			}
+
+			if code := gen(append(args, tc.args...)); code != tc.code {
+				t.Fatal("unexpected exit code: ", code)
+			}
+
+			written := testWritten(t, out)
+			if tc.code != 0 && len(written) > 0 {
+				t.Fatal("expected nothing written: ", written)
+			}
+			for glob, s := range tc.files {
+				found := false
+				for name, content := range written {
+					if ok, _ := filepath.Match(glob, name); !ok {
+						continue
+					}
+					found = true
+					if !strings.Contains(content, s) {
+						t.Fatal("expected in ", name, ": ", s, content)
+					}
+				}
+				if !found {
+					t.Fatal("expected written: ", glob, written)
+				}
+			}
+		})
+	}
+}
+
+func TestGenMissingInput(t *testing.T) {
+	out := t.TempDir()
+	if code := gen([]string{"-in", filepath.Join(out, "missing.md"), "-out", out}); code != 1 {
+		t.Fatal("unexpected exit code: ", code)
+	}
+	if code := gen([]string{"-in", testWrite(t, out, "schema.md", testMockSchemaShop), "-from", filepath.Join(out, "missing.md"), "-out", out}); code != 1 {
+		t.Fatal("unexpected exit code of a missing from: ", code)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Isaac799/devtoolbox/internal/strparse"
+)
+
+// importSchema prints the schema text of what exists elsewhere, a
+// database or a go package, so it can be adopted without retyping it.
+// It returns the exit code.
+func importSchema(args []string) int {
+	var (
+		flags  = flag.NewFlagSet("import", flag.ContinueOnError)
+		pgIn   = flags.String("postgres", "", "postgres ddl file to read, - for stdin")
+		goDir  = flags.String("go", "", "go package directory to read structs from")
+		result *strparse.Result
+		name   string
+	)
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	switch {
+	case len(*pgIn) > 0 && len(*goDir) == 0:
+		b, err := readInput(*pgIn)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		result, name = strparse.Postgres(string(b)), *pgIn
+	case len(*goDir) > 0 && len(*pgIn) == 0:
+		r, err := strparse.Go(*goDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		result, name = r, *goDir
+	default:
+		fmt.Fprintln(os.Stderr, "one of -postgres or -go is required")
+		return 2
+	}
+
+	for _, d := range result.Diagnostics {
+		fmt.Fprintf(os.Stderr, "%s:%s\n", name, d.String())
+	}
+
+	if len(result.Schemas) == 0 {
+		fmt.Fprintln(os.Stderr, "no schemas found")
+		return 1
+	}
+
+	fmt.Print(strparse.Format(result.Schemas))
+	return 0
+}
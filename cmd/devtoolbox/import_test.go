@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMockPostgresShop = `CREATE SCHEMA shop;
+
+CREATE TABLE shop.item (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(30) NOT NULL
+);`
+
+// testStdout is what the fn prints while it runs
+func testStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+
+	fn()
+
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestImportSchema(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ddl  string
+		args []string
+		code int
+		// expect is in what is printed
+		expect []string
+	}{
+		{
+			name:   "postgres",
+			ddl:    testMockPostgresShop,
+			args:   []string{"-postgres"},
+			expect: []string{"# shop", "## item", "- id as ++", "- name as"},
+		},
+		{
+			name: "nothing found",
+			ddl:  "-- nothing here",
+			args: []string{"-postgres"},
+			code: 1,
+		},
+		{
+			name: "no source",
+			code: 2,
+		},
+		{
+			name: "both sources",
+			ddl:  testMockPostgresShop,
+			args: []string{"-go", ".", "-postgres"},
+			code: 2,
+		},
+		{
+			name: "bad flag",
+			args: []string{"-bogus"},
+			code: 2,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			args := tc.args
+			if len(tc.ddl) > 0 {
+				args = append(args, testWrite(t, t.TempDir(), "dump.sql", tc.ddl))
+			}
+
+			var code int
+			printed := testStdout(t, func() { code = importSchema(args) })
+			if code != tc.code {
+				t.Fatal("unexpected exit code: ", code)
+			}
+			for _, s := range tc.expect {
+				if !strings.Contains(printed, s) {
+					t.Fatal("expected printed: ", s, printed)
+				}
+			}
+		})
+	}
+}
+
+func TestImportSchemaMissingInput(t *testing.T) {
+	if code := importSchema([]string{"-postgres", filepath.Join(t.TempDir(), "missing.sql")}); code != 1 {
+		t.Fatal("unexpected exit code: ", code)
+	}
+}
@@ -0,0 +1,34 @@
+// Package devtoolbox holds the templates and public assets that are
+// embedded into the binary, so it works from any directory.
+package devtoolbox
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed templates
+var templates embed.FS
+
+//go:embed public
+var public embed.FS
+
+// Templates provides the code generation templates, rooted at the templates directory
+func Templates() fs.FS {
+	sub, err := fs.Sub(templates, "templates")
+	if err != nil {
+		// unreachable, the directory is embedded
+		panic(err)
+	}
+	return sub
+}
+
+// Public provides the site pages, islands and assets, rooted at the public directory
+func Public() fs.FS {
+	sub, err := fs.Sub(public, "public")
+	if err != nil {
+		// unreachable, the directory is embedded
+		panic(err)
+	}
+	return sub
+}
@@ -7,6 +7,8 @@ import (
 	"strings"
 
 	"github.com/Isaac799/devtoolbox/internal"
+	"github.com/Isaac799/devtoolbox/internal/strgen"
+	"github.com/Isaac799/devtoolbox/internal/strparse"
 	"github.com/Isaac799/devtoolbox/pkg/model"
 )
 
@@ -17,6 +19,9 @@ const (
 	MaskDirtyExample
 	MaskDirtyFocus
 	MaskDirtyChroma
+	MaskDirtyNullable
+	MaskDirtyMigration
+	MaskDirtyDepth
 )
 
 // change is how we change a client based on a request
@@ -64,6 +69,37 @@ var changeExample = change(func(r *http.Request, c *Client) {
 	c.Input.Example = r.FormValue(k)
 })
 
+// changeImport replaces the entry with what postgres ddl describes,
+// written as text the same as an example is. An import that errs leaves
+// the entry as is, with why shown until the next output.
+var changeImport = change(func(r *http.Request, c *Client) {
+	const k = "import"
+	_, exists := r.Form[k]
+	if !exists {
+		return
+	}
+
+	result := strparse.Postgres(r.FormValue(k))
+	if len(result.Schemas) == 0 && !result.HasErr() {
+		result.Diagnostics = append(result.Diagnostics, strparse.Diagnostic{
+			Line:      1,
+			Column:    1,
+			ColumnEnd: 1,
+			Severity:  strparse.SeverityError,
+			Code:      strparse.CodeEmpty,
+			Message:   "no schemas found",
+		})
+	}
+	if result.HasErr() {
+		c.importDiagnostics = result.Diagnostics
+		return
+	}
+
+	c.SetQ(result.Schemas)
+	c.Dirty = c.Dirty | MaskDirtyExample
+	c.Input.Example = c.Input.Q
+})
+
 var changeFocus = change(func(r *http.Request, c *Client) {
 	const k = "focus"
 	_, exists := r.Form[k]
@@ -97,6 +133,62 @@ var changeChroma = change(func(r *http.Request, c *Client) {
 	c.Input.Chroma = useChroma
 })
 
+var changeNullable = change(func(r *http.Request, c *Client) {
+	const k = "nullable"
+	_, exists := r.Form[k]
+	if !exists {
+		return
+	}
+
+	nullable := strgen.GoNullablePointer
+	nullableInt, err := strconv.Atoi(r.FormValue(k))
+	if err == nil {
+		if nullableInt == int(strgen.GoNullableGeneric) {
+			nullable = strgen.GoNullableGeneric
+		}
+	}
+
+	c.Dirty = c.Dirty | MaskDirtyNullable
+	c.Input.Nullable = nullable
+})
+
+var changeMigration = change(func(r *http.Request, c *Client) {
+	const k = "migration"
+	_, exists := r.Form[k]
+	if !exists {
+		return
+	}
+
+	migration := strgen.MigrationUnix
+	migrationInt, err := strconv.Atoi(r.FormValue(k))
+	if err == nil {
+		switch strgen.MigrationStyle(migrationInt) {
+		case strgen.MigrationSequence, strgen.MigrationGoose, strgen.MigrationDbmate:
+			migration = strgen.MigrationStyle(migrationInt)
+		}
+	}
+
+	c.Dirty = c.Dirty | MaskDirtyMigration
+	c.Input.Migration = migration
+})
+
+var changeDepth = change(func(r *http.Request, c *Client) {
+	const k = "depth"
+	_, exists := r.Form[k]
+	if !exists {
+		return
+	}
+
+	depth := model.DefaultDepth
+	depthInt, err := strconv.Atoi(r.FormValue(k))
+	if err == nil && depthInt >= 1 {
+		depth = depthInt
+	}
+
+	c.Dirty = c.Dirty | MaskDirtyDepth
+	c.Input.Depth = depth
+})
+
 func newSchemaFromRequest(r *http.Request) *model.Schema {
 	return &model.Schema{
 		ID:   r.FormValue("SchemaID"),
@@ -106,8 +198,11 @@ func newSchemaFromRequest(r *http.Request) *model.Schema {
 
 func newEntityFromRequest(r *http.Request) *model.Entity {
 	return &model.Entity{
-		ID:   r.FormValue("EntityID"),
-		Name: r.FormValue("EntityName"),
+		ID:         r.FormValue("EntityID"),
+		Name:       r.FormValue("EntityName"),
+		Timestamps: r.FormValue("EntityTimestamps") == "true",
+		SoftDelete: r.FormValue("EntitySoftDelete") == "true",
+		Versioned:  r.FormValue("EntityVersioned") == "true",
 	}
 }
 
@@ -125,9 +220,13 @@ func newAttributeFromRequest(r *http.Request) *model.AttributeRaw {
 		attributeDefault     = r.FormValue("AttributeDefault")
 		attributeReferenceTo = r.FormValue("AttributeReferenceTo")
 		attributeAlias       = r.FormValue("AttributeAlias")
+		attributeEnum        = r.FormValue("AttributeEnum")
+		attributeArray       = r.FormValue("AttributeArray")
+		attributeOnDelete, _ = strconv.Atoi(r.FormValue("AttributeOnDelete"))
+		attributeOnUpdate, _ = strconv.Atoi(r.FormValue("AttributeOnUpdate"))
 	)
 
-	if attributeKind > 14 || attributeKind < 0 {
+	if attributeKind > 20 || attributeKind < 0 {
 		attributeKind = 0
 	}
 
@@ -171,15 +270,28 @@ func newAttributeFromRequest(r *http.Request) *model.AttributeRaw {
 			Required: sql.NullBool{Bool: attributeRequired == "true", Valid: len(attributeRequired) > 0},
 		},
 		Primary:      attributePrimary == "true",
+		Array:        attributeArray == "true",
 		Alias:        internal.Normalize(attributeAlias),
 		DefaultValue: strings.TrimSpace(attributeDefault),
 		Unique:       cleanUniqueLabels,
+		EnumValues:   strings.Split(attributeEnum, ","),
+		OnDelete:     refActionFromRequest(attributeOnDelete),
+		OnUpdate:     refActionFromRequest(attributeOnUpdate),
 		Err:          []error{},
+		Warn:         []error{},
 	}
 
 	return &raw
 }
 
+// refActionFromRequest is the action selected, none if it is not one
+func refActionFromRequest(i int) model.RefAction {
+	if i > int(model.RefActionRestrict) || i < 0 {
+		return model.RefActionNone
+	}
+	return model.RefAction(i)
+}
+
 var changeSchema = change(func(r *http.Request, c *Client) {
 	if c.Input.Focus.Schema == nil {
 		return
@@ -202,8 +314,10 @@ var changeEntity = change(func(r *http.Request, c *Client) {
 	oldEnt := *c.Input.Focus.Entity
 
 	ent.ID = oldEnt.ID
+	ent.Parent = oldEnt.Parent
+	ent.RawAttributes = oldEnt.RawAttributes
 
-	ent.ClearCache()
+	ent.EnsureOptionAttributes()
 
 	*c.Input.Focus.Entity = ent
 })
@@ -225,10 +339,14 @@ var changeAttribute = change(func(r *http.Request, c *Client) {
 
 	attr.EnsureValidAlias(oldAttr.Parent)
 	attr.EnsureValidName(oldAttr.Parent)
+	attr.SanitizeEnumValues()
 	attr.EnsureValidRange()
 	attr.MaybeRequireValidation()
+	attr.EnsureValidPrimary()
+	attr.EnsureValidArray()
+	attr.EnsureValidAction()
 	attr.SanitizeDefaultValue()
-	attr.SanativeSerialKind()
+	attr.SanativeGeneratedKind()
 
 	attr.Parent.ClearCache()
 
@@ -3,7 +3,6 @@ package site
 import (
 	"crypto/rand"
 	"net/http"
-	"strings"
 	"sync"
 	"time"
 
@@ -32,6 +31,8 @@ type Client struct {
 	Input      Input
 	LastOutput *Output
 	Dirty      uint
+	// importDiagnostics are of an import that erred, for the next output
+	importDiagnostics []strparse.Diagnostic
 }
 
 func (c *Client) extendLife() {
@@ -65,30 +66,7 @@ func (c *Client) change(r *http.Request, changes ...change) {
 
 // SetQ will set the main query string related to a client
 func (c *Client) SetQ(schemas []*model.Schema) {
-	sb := strings.Builder{}
-
-	for si, schema := range schemas {
-		if si > 0 {
-			sb.WriteString("\n")
-		}
-		sb.WriteString(schema.String())
-		sb.WriteString("\n")
-		sb.WriteString("\n")
-		for ei, entity := range schema.Entities {
-			if ei > 0 {
-				sb.WriteString("\n")
-			}
-			sb.WriteString(entity.String())
-			sb.WriteString("\n")
-			sb.WriteString("\n")
-			for _, attr := range entity.RawAttributes {
-				sb.WriteString(attr.String())
-				sb.WriteString("\n")
-			}
-		}
-	}
-
-	c.Input.Q = sb.String()
+	c.Input.Q = strparse.Format(schemas)
 }
 
 func (c *Client) clearFocus() {
@@ -143,12 +121,18 @@ func (c *Client) SetOutput() error {
 		c.LastOutput = emptyLastOutput(schemas)
 	}
 
+	diagnostics := make([]strparse.Diagnostic, 0)
+
 	if len(c.Input.Example) > 0 {
-		schemas = strparse.Raw(c.Input.Example)
+		result := strparse.ParseWith(c.Input.Example, c.Input.ParseOptions())
+		schemas = result.Schemas
+		diagnostics = result.Diagnostics
 		c.Input.Q = c.Input.Example
 		c.Input.Example = ""
 	} else if c.Input.Mode == InputModeText {
-		schemas = strparse.Raw(c.Input.Q)
+		result := strparse.ParseWith(c.Input.Q, c.Input.ParseOptions())
+		schemas = result.Schemas
+		diagnostics = result.Diagnostics
 	} else {
 		schemas = c.LastOutput.Schemas
 	}
@@ -160,13 +144,13 @@ func (c *Client) SetOutput() error {
 		c.clearFocus()
 	}
 
-	pgFiles, err := strgen.PostgresSetup(schemas)
+	pgFiles, err := strgen.PostgresSetup(schemas, c.Input.MigrationOptions())
 	if err != nil {
 		c.LastOutput = emptyLastOutput(schemas)
 		return err
 	}
 
-	goFiles, err := strgen.GoStructs(schemas)
+	goFiles, err := strgen.GoStructs(schemas, c.Input.GoOptions())
 	if err != nil {
 		c.LastOutput = emptyLastOutput(schemas)
 		return err
@@ -178,11 +162,36 @@ func (c *Client) SetOutput() error {
 		return err
 	}
 
-	var hasErr bool
-	for _, s := range c.LastOutput.Schemas {
+	openAPIFiles, err := strgen.OpenAPI(schemas)
+	if err != nil {
+		c.LastOutput = emptyLastOutput(schemas)
+		return err
+	}
+
+	tsFiles, err := strgen.TypeScript(schemas)
+	if err != nil {
+		c.LastOutput = emptyLastOutput(schemas)
+		return err
+	}
+
+	jsonSchemaFiles, err := strgen.JSONSchema(schemas)
+	if err != nil {
+		c.LastOutput = emptyLastOutput(schemas)
+		return err
+	}
+
+	var hasErr, hasWarn bool
+	if len(c.importDiagnostics) > 0 {
+		diagnostics = append(c.importDiagnostics, diagnostics...)
+		c.importDiagnostics = nil
+		hasErr = true
+	}
+	for _, s := range schemas {
 		if s.HasErr() {
 			hasErr = true
-			break
+		}
+		if s.HasWarn() {
+			hasWarn = true
 		}
 	}
 
@@ -194,11 +203,16 @@ func (c *Client) SetOutput() error {
 
 	out := Output{
 		Schemas:        schemas,
+		Diagnostics:    diagnostics,
 		HasErr:         hasErr,
+		HasWarn:        hasWarn,
 		OkayToDownload: len(schemas) > 0 && !hasErr,
 		GoGen:          goFiles,
 		PgGen:          pgFiles,
 		HurlGen:        hurlFiles,
+		OpenAPIGen:     openAPIFiles,
+		TSGen:          tsFiles,
+		JSONSchemaGen:  jsonSchemaFiles,
 	}
 
 	c.LastOutput = &out
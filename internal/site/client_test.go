@@ -0,0 +1,75 @@
+package site
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Isaac799/devtoolbox"
+	"github.com/Isaac799/devtoolbox/internal/strgen"
+	"github.com/Isaac799/devtoolbox/pkg/model"
+	"github.com/Isaac799/devtoolbox/pkg/tmplcache"
+)
+
+// BenchmarkClientSetOutput compares generating output when templates are
+// parsed every time, as they used to be, against parsing them once
+func BenchmarkClientSetOutput(b *testing.B) {
+	defer strgen.UseTemplates(devtoolbox.Templates(), tmplcache.New(false))
+
+	cases := []struct {
+		name   string
+		reload bool
+	}{
+		{name: "reload", reload: true},
+		{name: "cached", reload: false},
+	}
+
+	for _, tc := range cases {
+		for _, example := range DefaultExamples() {
+			b.Run(tc.name+"/"+example.Label, func(b *testing.B) {
+				strgen.UseTemplates(devtoolbox.Templates(), tmplcache.New(tc.reload))
+
+				client := NewClient()
+				client.Input.Mode = InputModeText
+				client.Input.Q = example.Value
+
+				b.ReportAllocs()
+				for b.Loop() {
+					if err := client.SetOutput(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestChangeDepth(t *testing.T) {
+	for _, tc := range []struct {
+		value  string
+		expect int
+	}{
+		{value: "2", expect: 2},
+		{value: "0", expect: model.DefaultDepth},
+		{value: "deep", expect: model.DefaultDepth},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/change", strings.NewReader(url.Values{"depth": {tc.value}}.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if err := r.ParseForm(); err != nil {
+				t.Fatal(err)
+			}
+
+			client := NewClient()
+			changeDepth(r, client)
+			if client.Dirty&MaskDirtyDepth == 0 {
+				t.Fatal("expected depth dirty")
+			}
+			if depth := client.Input.ParseOptions().Depth; depth != tc.expect {
+				t.Fatal("unexpected depth: ", depth)
+			}
+		})
+	}
+}
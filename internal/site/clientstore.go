@@ -4,13 +4,17 @@ import (
 	"archive/zip"
 	"bytes"
 	"fmt"
+	"io/fs"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/Isaac799/devtoolbox/internal/strgen"
 	"github.com/Isaac799/devtoolbox/internal/strparse"
+	"github.com/Isaac799/devtoolbox/pkg/tmplcache"
 )
 
 // ClientStore is my way of tracking multiple clients server side
@@ -18,17 +22,35 @@ type ClientStore struct {
 	mu      sync.Mutex
 	cap     int
 	clients []*Client
+
+	// public is where pages, islands, and forms are read from
+	public fs.FS
+	// cache keeps parsed pages, islands, and forms
+	cache *tmplcache.Cache
 }
 
-// NewClientStore provides a the client store with good cap
-func NewClientStore(cap int) *ClientStore {
+// NewClientStore provides a the client store with good cap,
+// reading pages, islands, and forms from public and keeping
+// them parsed in cache
+func NewClientStore(cap int, public fs.FS, cache *tmplcache.Cache) *ClientStore {
 	return &ClientStore{
 		mu:      sync.Mutex{},
 		cap:     cap,
 		clients: make([]*Client, 0, cap),
+		public:  public,
+		cache:   cache,
 	}
 }
 
+// template provides a cached template made of the files matching patterns,
+// named after the first file
+func (store *ClientStore) template(patterns ...string) (*template.Template, error) {
+	key := strings.Join(patterns, ",")
+	return store.cache.Get(key, func() (*template.Template, error) {
+		return template.ParseFS(store.public, patterns...)
+	})
+}
+
 func (store *ClientStore) preserve(c *Client) {
 	store.mu.Lock()
 	defer store.mu.Unlock()
@@ -116,7 +138,7 @@ func (store *ClientStore) Download() http.HandlerFunc {
 			return
 		}
 
-		schemas := strparse.Raw(client.Input.Q)
+		schemas := strparse.ParseWith(client.Input.Q, client.Input.ParseOptions()).Schemas
 
 		if len(schemas) == 0 {
 			w.WriteHeader(http.StatusBadRequest)
@@ -126,7 +148,7 @@ func (store *ClientStore) Download() http.HandlerFunc {
 		buff := bytes.NewBuffer(nil)
 		zWriter := zip.NewWriter(buff)
 
-		goGen, err := strgen.GoStructs(schemas)
+		goGen, err := strgen.GoStructs(schemas, client.Input.GoOptions())
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -145,7 +167,7 @@ func (store *ClientStore) Download() http.HandlerFunc {
 			}
 		}
 
-		pgGen, err := strgen.PostgresSetup(schemas)
+		pgGen, err := strgen.PostgresSetup(schemas, client.Input.MigrationOptions())
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			return
@@ -181,6 +203,60 @@ func (store *ClientStore) Download() http.HandlerFunc {
 			}
 		}
 
+		openAPIGen, err := strgen.OpenAPI(schemas)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for k, v := range openAPIGen {
+			zw, err := zWriter.Create(k.Full())
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, err = zw.Write([]byte(v))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		tsGen, err := strgen.TypeScript(schemas)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for k, v := range tsGen {
+			zw, err := zWriter.Create(k.Full())
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, err = zw.Write([]byte(v))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
+		jsonSchemaGen, err := strgen.JSONSchema(schemas)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		for k, v := range jsonSchemaGen {
+			zw, err := zWriter.Create(k.Full())
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, err = zw.Write([]byte(v))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+		}
+
 		zWriter.Close()
 		archName := fmt.Sprintf("devtoolbox-%d", time.Now().Unix())
 		b := buff.Bytes()
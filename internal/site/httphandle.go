@@ -3,10 +3,7 @@ package site
 import (
 	"fmt"
 	"net/http"
-	"os"
-	"path/filepath"
 	"slices"
-	"text/template"
 
 	"github.com/Isaac799/devtoolbox/pkg/htmx"
 	"github.com/Isaac799/devtoolbox/pkg/model"
@@ -17,16 +14,6 @@ import (
 // No access to client information
 // Limit access to public islands
 func (store *ClientStore) HandlePageHome(w http.ResponseWriter, r *http.Request) {
-	var (
-		wd, err = os.Getwd()
-		tmpl    *template.Template
-	)
-	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
 	client, _ := store.clientInfo(w, r)
 	if client == nil {
 		client = NewClient()
@@ -36,32 +23,11 @@ func (store *ClientStore) HandlePageHome(w http.ResponseWriter, r *http.Request)
 
 	client.setSessionCookie(w)
 
-	{
-		s := filepath.Join(wd, "public", "page", "home.html")
-		tmpl, err = template.ParseFiles(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-	}
-	{
-		s := filepath.Join(wd, "public", "island", "*.pub.html")
-		tmpl, err = tmpl.ParseGlob(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-	}
-	{
-		s := filepath.Join(wd, "public", "form", "*.html")
-		tmpl, err = tmpl.ParseGlob(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	tmpl, err := store.template("page/home.html", "island/*.pub.html", "form/*.html")
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
@@ -74,35 +40,13 @@ func (store *ClientStore) HandlePageHome(w http.ResponseWriter, r *http.Request)
 // No access to client information
 // Limit access to public islands
 func (store *ClientStore) HandlePageHelp(w http.ResponseWriter, _ *http.Request) {
-	var (
-		wd, err = os.Getwd()
-		tmpl    *template.Template
-	)
+	tmpl, err := store.template("page/help.html", "island/*.pub.html")
 	if err != nil {
 		fmt.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	{
-		s := filepath.Join(wd, "public", "page", "help.html")
-		tmpl, err = template.ParseFiles(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-	}
-	{
-		s := filepath.Join(wd, "public", "island", "*.pub.html")
-		tmpl, err = tmpl.ParseGlob(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-	}
-
 	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
 	w.Header().Add("Content-Type", "text/html")
 	tmpl.Execute(w, nil)
@@ -113,35 +57,13 @@ func (store *ClientStore) HandlePageHelp(w http.ResponseWriter, _ *http.Request)
 // No access to client information
 // Limit access to public islands
 func (store *ClientStore) HandlePageAbout(w http.ResponseWriter, _ *http.Request) {
-	var (
-		wd, err = os.Getwd()
-		tmpl    *template.Template
-	)
+	tmpl, err := store.template("page/about.html", "island/*.pub.html")
 	if err != nil {
 		fmt.Println(err)
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	{
-		s := filepath.Join(wd, "public", "page", "about.html")
-		tmpl, err = template.ParseFiles(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-	}
-	{
-		s := filepath.Join(wd, "public", "island", "*.pub.html")
-		tmpl, err = tmpl.ParseGlob(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-	}
-
 	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
 	w.Header().Add("Content-Type", "text/html")
 	tmpl.Execute(w, nil)
@@ -152,17 +74,7 @@ func (store *ClientStore) HandlePageAbout(w http.ResponseWriter, _ *http.Request
 // Has access to client information
 // Has access to islands
 func (store *ClientStore) HandleDialog(w http.ResponseWriter, r *http.Request) {
-	var (
-		wd, err = os.Getwd()
-		tmpl    *template.Template
-	)
-	if err != nil {
-		fmt.Println(err)
-		w.WriteHeader(http.StatusInternalServerError)
-		return
-	}
-
-	acceptable := []string{"example", "setting"}
+	acceptable := []string{"example", "import", "setting"}
 	what := r.PathValue("what")
 	if !slices.Contains(acceptable, what) {
 		w.WriteHeader(http.StatusUnprocessableEntity)
@@ -179,32 +91,11 @@ func (store *ClientStore) HandleDialog(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	{
-		s := filepath.Join(wd, "public", "dialog", what+".html")
-		tmpl, err = template.ParseFiles(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-	}
-	{
-		s := filepath.Join(wd, "public", "island", "*.pub.html")
-		tmpl, err = tmpl.ParseGlob(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-	}
-	{
-		s := filepath.Join(wd, "public", "form", "*.html")
-		tmpl, err = tmpl.ParseGlob(s)
-		if err != nil {
-			fmt.Println(err)
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
+	tmpl, err := store.template("dialog/"+what+".html", "island/*.pub.html", "form/*.html")
+	if err != nil {
+		fmt.Println(err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
 	}
 
 	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0")
@@ -242,13 +133,13 @@ func (store *ClientStore) HandleIsland(w http.ResponseWriter, r *http.Request) {
 
 	switch what {
 	case "input":
-		if err := oobSwapper.Write(oobSwapInput(client)); err != nil {
+		if err := oobSwapper.Write(store.oobSwapInput(client)); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Println(err)
 			return
 		}
 	case "output":
-		if err := oobSwapper.Write(oobSwapOutput(client)); err != nil {
+		if err := oobSwapper.Write(store.oobSwapOutput(client)); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Println(err)
 			return
@@ -281,7 +172,7 @@ func (store *ClientStore) HandleChange(w http.ResponseWriter, r *http.Request) {
 
 	client.change(r, changeFocus)
 	if client.Dirty&MaskDirtyFocus == MaskDirtyFocus {
-		if err := oobSwapper.Write(oobSwapInput(client)); err != nil {
+		if err := oobSwapper.Write(store.oobSwapInput(client)); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Println(err)
 			return
@@ -293,7 +184,7 @@ func (store *ClientStore) HandleChange(w http.ResponseWriter, r *http.Request) {
 
 	client.change(r, changeChroma)
 	if client.Dirty&MaskDirtyChroma == MaskDirtyChroma {
-		if err := oobSwapper.Write(oobSwapOutput(client)); err != nil {
+		if err := oobSwapper.Write(store.oobSwapOutput(client)); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Println(err)
 			return
@@ -305,7 +196,7 @@ func (store *ClientStore) HandleChange(w http.ResponseWriter, r *http.Request) {
 
 	client.change(
 		r,
-		changeExample, changeQ, changeMode,
+		changeExample, changeImport, changeQ, changeMode, changeNullable, changeMigration, changeDepth,
 		changeSchema, changeEntity, changeAttribute,
 	)
 
@@ -313,7 +204,7 @@ func (store *ClientStore) HandleChange(w http.ResponseWriter, r *http.Request) {
 		client.clearFocus()
 	}
 
-	client.LastOutput.refreshSchemas()
+	client.LastOutput.refreshSchemas(client.Input.ParseOptions())
 	err := client.SetOutput()
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -321,20 +212,20 @@ func (store *ClientStore) HandleChange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if client.Dirty&MaskDirtyQ == MaskDirtyQ {
-		if err := oobSwapper.Write(oobSwapOutput(client)); err != nil {
+	if client.Dirty&(MaskDirtyQ|MaskDirtyNullable|MaskDirtyMigration|MaskDirtyDepth) != 0 {
+		if err := oobSwapper.Write(store.oobSwapOutput(client)); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Println(err)
 			return
 		}
 	} else if client.Dirty&MaskDirtyFocus == MaskDirtyFocus {
-		if err := oobSwapper.Write(oobSwapInput(client)); err != nil {
+		if err := oobSwapper.Write(store.oobSwapInput(client)); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Println(err)
 			return
 		}
 	} else {
-		if err := oobSwapper.Write(oobSwapInput(client), oobSwapOutput(client)); err != nil {
+		if err := oobSwapper.Write(store.oobSwapInput(client), store.oobSwapOutput(client)); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			fmt.Println(err)
 			return
@@ -398,7 +289,7 @@ func (store *ClientStore) HandleChildPost(w http.ResponseWriter, r *http.Request
 
 	oobSwapper := htmx.NewOobSwapper()
 
-	if err := oobSwapper.Write(oobSwapInput(client), oobSwapOutput(client)); err != nil {
+	if err := oobSwapper.Write(store.oobSwapInput(client), store.oobSwapOutput(client)); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Println(err)
 		return
@@ -456,12 +347,12 @@ func (store *ClientStore) HandleChildDelete(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	client.LastOutput.refreshSchemas()
+	client.LastOutput.refreshSchemas(client.Input.ParseOptions())
 	client.clearFocus()
 	client.SetOutput()
 
 	oobSwapper := htmx.NewOobSwapper()
-	if err := oobSwapper.Write(oobSwapInput(client), oobSwapOutput(client)); err != nil {
+	if err := oobSwapper.Write(store.oobSwapInput(client), store.oobSwapOutput(client)); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		fmt.Println(err)
 		return
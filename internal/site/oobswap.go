@@ -3,8 +3,6 @@ package site
 import (
 	"bytes"
 	"fmt"
-	"os"
-	"path/filepath"
 	"text/template"
 	"unicode/utf8"
 
@@ -20,93 +18,106 @@ type templateData struct {
 	Examples []Example
 }
 
-func oobSwapInput(client *Client) htmx.OobSwap {
+// _outputFuncs are used when rendering output. They must not depend on
+// a client since the parsed template is shared between all of them.
+var _outputFuncs = template.FuncMap{
+	"stringSize": func(s string) string {
+		return fmt.Sprintf("%d bytes", utf8.RuneCountInString(s))
+	},
+	"chroma": func(s string, useLexer string) string {
+		lexer := lexers.Get(useLexer)
+		lexer = chroma.Coalesce(lexer)
+		style := styles.Get("github")
+		if style == nil {
+			style = styles.Fallback
+		}
+		formatter := formatters.Get("html")
+		if formatter == nil {
+			formatter = formatters.Fallback
+		}
+		iterator, err := lexer.Tokenise(nil, s)
+		if err != nil {
+			fmt.Println(err)
+			return s
+		}
+		buff := bytes.NewBuffer(nil)
+		err = formatter.Format(buff, style, iterator)
+		if err != nil {
+			fmt.Println(err)
+			return s
+		}
+		return buff.String()
+	},
+}
+
+func (store *ClientStore) oobSwapInput(client *Client) htmx.OobSwap {
 	data := templateData{
 		Client:   client,
 		Examples: DefaultExamples(),
 	}
 
-	wd, _ := os.Getwd()
-
 	withInputs := func(t *template.Template) error {
-		if _, err := t.ParseGlob(filepath.Join(wd, "public", "island", "input-*.html")); err != nil {
+		if _, err := t.ParseFS(store.public, "island/input-*.html"); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	withDiagnostics := func(t *template.Template) error {
+		if _, err := t.ParseFS(store.public, "island/diagnostics.html"); err != nil {
 			return err
 		}
 		return nil
 	}
 
 	withForms := func(t *template.Template) error {
-		if _, err := t.ParseGlob(filepath.Join(wd, "public", "form", "*.html")); err != nil {
+		if _, err := t.ParseFS(store.public, "form/*.html"); err != nil {
 			return err
 		}
 		return nil
 	}
 
-	return htmx.NewOobSwap(
-		filepath.Join(wd, "public", "island", "input.html"),
+	return htmx.NewOobSwapCached(
+		store.cache,
+		store.public,
+		"island/input.html",
 		"input",
 		data,
-		withInputs, withForms,
+		withInputs, withForms, withDiagnostics,
 	)
 }
 
-func oobSwapOutput(client *Client) htmx.OobSwap {
+func (store *ClientStore) oobSwapOutput(client *Client) htmx.OobSwap {
 	data := templateData{
 		Client:   client,
 		Examples: DefaultExamples(),
 	}
 
-	wd, _ := os.Getwd()
-
 	withOutputs := func(t *template.Template) error {
-		t, err := t.ParseGlob(filepath.Join(wd, "public", "island", "output-*.html"))
-		if err != nil {
+		if _, err := t.ParseFS(store.public, "island/output-*.html"); err != nil {
 			return err
 		}
 		return nil
 	}
 
-	withFuncMap := func(t *template.Template) error {
-		t.Funcs(template.FuncMap{
-			"stringSize": func(s string) string {
-				return fmt.Sprintf("%d bytes", utf8.RuneCountInString(s))
-			},
-			"chroma": func(s string, useLexer string) string {
-				if !client.Input.Chroma {
-					return s
-				}
+	withDiagnostics := func(t *template.Template) error {
+		if _, err := t.ParseFS(store.public, "island/diagnostics.html"); err != nil {
+			return err
+		}
+		return nil
+	}
 
-				lexer := lexers.Get(useLexer)
-				lexer = chroma.Coalesce(lexer)
-				style := styles.Get("github")
-				if style == nil {
-					style = styles.Fallback
-				}
-				formatter := formatters.Get("html")
-				if formatter == nil {
-					formatter = formatters.Fallback
-				}
-				iterator, err := lexer.Tokenise(nil, s)
-				if err != nil {
-					fmt.Println(err)
-					return s
-				}
-				buff := bytes.NewBuffer(nil)
-				err = formatter.Format(buff, style, iterator)
-				if err != nil {
-					fmt.Println(err)
-					return s
-				}
-				return buff.String()
-			},
-		})
+	withFuncMap := func(t *template.Template) error {
+		t.Funcs(_outputFuncs)
 		return nil
 	}
 
-	return htmx.NewOobSwap(
-		filepath.Join(wd, "public", "island", "output.html"),
+	return htmx.NewOobSwapCached(
+		store.cache,
+		store.public,
+		"island/output.html",
 		"output",
 		data,
-		withFuncMap, withOutputs,
+		withFuncMap, withOutputs, withDiagnostics,
 	)
 }
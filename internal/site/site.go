@@ -2,6 +2,7 @@ package site
 
 import (
 	"github.com/Isaac799/devtoolbox/internal/strgen"
+	"github.com/Isaac799/devtoolbox/internal/strparse"
 	"github.com/Isaac799/devtoolbox/pkg/model"
 )
 
@@ -32,19 +33,48 @@ type Input struct {
 	Focus   Focus
 	Mode    InputMode
 	Chroma  bool
+	// Nullable is how go holds an attribute that may be null
+	Nullable strgen.GoNullable
+	// Migration is how migration files are named
+	Migration strgen.MigrationStyle
+	// Depth is how many references deep keys are flattened, zero being
+	// the model default
+	Depth int
+}
+
+// GoOptions are the preferences of a user for generated go
+func (in Input) GoOptions() strgen.GoOptions {
+	return strgen.GoOptions{Nullable: in.Nullable}
+}
+
+// MigrationOptions are the preferences of a user for generated migrations
+func (in Input) MigrationOptions() strgen.MigrationOptions {
+	return strgen.MigrationOptions{Style: in.Migration}
+}
+
+// ParseOptions are how the schemas of a user are parsed, and held to
+// as they are changed
+func (in Input) ParseOptions() strparse.Options {
+	return strparse.Options{Depth: in.Depth}
 }
 
 // Output is for template rendering to show what was generated
 type Output struct {
 	Schemas        []*model.Schema
+	Diagnostics    []strparse.Diagnostic
 	GoGen          map[strgen.FileName]string
 	PgGen          map[strgen.FileName]string
 	HurlGen        map[strgen.FileName]string
+	OpenAPIGen     map[strgen.FileName]string
+	TSGen          map[strgen.FileName]string
+	JSONSchemaGen  map[strgen.FileName]string
 	OkayToDownload bool
 	HasErr         bool
+	// HasWarn does not prevent download
+	HasWarn bool
 }
 
-func (out *Output) refreshSchemas() {
+func (out *Output) refreshSchemas(opts strparse.Options) {
 	for _, schema := range out.Schemas {
 		for _, entity := range schema.Entities {
 			entity.ClearCache()
@@ -56,6 +86,7 @@ func (out *Output) refreshSchemas() {
 			}
 		}
 	}
+	model.SetDepth(out.Schemas, opts.Depth)
 }
 
 // Example is preset to show functionality
@@ -82,11 +113,11 @@ func DefaultExamples() []Example {
 
 ## Category
 - id as ++
-- name as str with u, r, 3..30
+- name as str with u:name, r, 3..30
 
 ## Book
 - id as ++
-- title as str with u, r, ..50
+- title as str with u:title, r, ..50
 - published as ts with default:now
 - @category with r
 
@@ -109,9 +140,13 @@ func DefaultExamples() []Example {
 
 func emptyLastOutput(schemas []*model.Schema) *Output {
 	return &Output{
-		Schemas: schemas,
-		GoGen:   make(map[strgen.FileName]string),
-		PgGen:   make(map[strgen.FileName]string, 0),
-		HurlGen: make(map[strgen.FileName]string, 0),
+		Schemas:       schemas,
+		Diagnostics:   make([]strparse.Diagnostic, 0),
+		GoGen:         make(map[strgen.FileName]string),
+		PgGen:         make(map[strgen.FileName]string, 0),
+		HurlGen:       make(map[strgen.FileName]string, 0),
+		OpenAPIGen:    make(map[strgen.FileName]string, 0),
+		TSGen:         make(map[strgen.FileName]string, 0),
+		JSONSchemaGen: make(map[strgen.FileName]string, 0),
 	}
 }
@@ -2,14 +2,45 @@ package strgen
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
 
+	"github.com/Isaac799/devtoolbox/internal"
 	"github.com/Isaac799/devtoolbox/pkg/model"
 	"github.com/iancoleman/strcase"
 )
 
+// GoNullable is how an attribute that may be null is held in go
+type GoNullable int
+
+// recognized ways to hold a nullable attribute
+const (
+	// GoNullablePointer holds it as a pointer, nil when null
+	GoNullablePointer GoNullable = iota
+	// GoNullableGeneric holds it in a generated generic Null[T]
+	GoNullableGeneric
+)
+
+// GoDialect is the sql database the generated store queries
+type GoDialect int
+
+// recognized sql dialects of the generated store
+const (
+	// GoDialectPostgres numbers placeholders as $n and casts where needed
+	GoDialectPostgres GoDialect = iota
+	// GoDialectSQLite uses ? placeholders without casts, and prefixes
+	// a table with its schema since sqlite has no schemas
+	GoDialectSQLite
+)
+
+// GoOptions tune the generated go code
+type GoOptions struct {
+	Nullable GoNullable
+	Dialect  GoDialect
+}
+
 var _goKind = map[model.AttrKind]string{
 	model.AttrKindNone:      "???",
 	model.AttrKindReference: "???",
@@ -26,6 +57,25 @@ var _goKind = map[model.AttrKind]string{
 	model.AttrKindReal:      "float64",
 	model.AttrKindDecimal:   "float64",
 	model.AttrKindMoney:     "float64",
+	model.AttrKindEnum:      "string",
+
+	model.AttrKindUUID:          "string",
+	model.AttrKindGeneratedUUID: "string",
+	model.AttrKindText:          "string",
+	model.AttrKindJSON:          "json.RawMessage",
+	model.AttrKindBytes:         "[]byte",
+}
+
+// _goArrayKind is the element of a slice for an array kind, limited
+// to what the driver can scan into, with other kinds held as text
+var _goArrayKind = map[model.AttrKind]string{
+	model.AttrKindInt:     "int64",
+	model.AttrKindBoolean: "bool",
+	model.AttrKindFloat:   "float64",
+	model.AttrKindReal:    "float64",
+	model.AttrKindDecimal: "float64",
+	model.AttrKindMoney:   "float64",
+	model.AttrKindBytes:   "[]byte",
 }
 
 var _goZeroVue = map[model.AttrKind]string{
@@ -44,6 +94,13 @@ var _goZeroVue = map[model.AttrKind]string{
 	model.AttrKindReal:      "0.0",
 	model.AttrKindDecimal:   "0.0",
 	model.AttrKindMoney:     "0.0",
+	model.AttrKindEnum:      `""`,
+
+	model.AttrKindUUID:          `""`,
+	model.AttrKindGeneratedUUID: `""`,
+	model.AttrKindText:          `""`,
+	model.AttrKindJSON:          "nil",
+	model.AttrKindBytes:         "nil",
 }
 
 func renderGoErrs(attr *model.Attribute) string {
@@ -60,6 +117,84 @@ func renderPrimaryPlaceholder(ent *model.Entity, i int) int {
 	return i + 1 + len(ent.AttributesToUpdate())
 }
 
+// renderManagedSet is what an update sets beyond the fields of a record,
+// for the options of its entity
+func renderManagedSet(ent *model.Entity) string {
+	sets := make([]string, 0, 2)
+	if ent.Timestamps {
+		sets = append(sets, fmt.Sprintf("%s=current_timestamp", model.AttrNameUpdatedAt))
+	}
+	if ent.Versioned {
+		sets = append(sets, fmt.Sprintf("%s=%s + 1", model.AttrNameVersion, model.AttrNameVersion))
+	}
+	if len(sets) == 0 {
+		return ""
+	}
+	s := strings.Join(sets, ", ")
+	if len(ent.AttributesToUpdate()) > 0 {
+		s = ", " + s
+	}
+	return s
+}
+
+// renderPlaceholder is the query placeholder of the nth arg, counted from 1
+func renderPlaceholder(n int, opts GoOptions) string {
+	if opts.Dialect == GoDialectSQLite {
+		return "?"
+	}
+	return fmt.Sprintf("$%d", n)
+}
+
+// renderTable is the name of an entity in a query
+func renderTable(ent *model.Entity, opts GoOptions) string {
+	if opts.Dialect == GoDialectSQLite {
+		return renderSQLiteTable(ent)
+	}
+	return fmt.Sprintf("%s.%s", ent.Parent.Name, ent.Name)
+}
+
+// renderTableFrom is the name of an entity in a query that qualifies
+// columns by the table name, so a prefixed sqlite table is aliased
+func renderTableFrom(ent *model.Entity, opts GoOptions) string {
+	if opts.Dialect == GoDialectSQLite {
+		return fmt.Sprintf("%s AS %s", renderSQLiteTable(ent), ent.Name)
+	}
+	return renderTable(ent, opts)
+}
+
+// renderJoinAs is how what a relation has is named in a query, apart
+// from the base if an entity relates to itself
+func renderJoinAs(rel model.Relation) string {
+	if rel.Self() {
+		return rel.HasName()
+	}
+	return rel.Has.Name
+}
+
+// renderJoinFrom is what a relation has in a query, named by renderJoinAs
+func renderJoinFrom(rel model.Relation, opts GoOptions) string {
+	if rel.Self() {
+		return fmt.Sprintf("%s AS %s", renderTable(rel.Has, opts), renderJoinAs(rel))
+	}
+	return renderTableFrom(rel.Has, opts)
+}
+
+// renderTreeKeys are the columns of an entity that reference its own key,
+// of the first reference it has to itself, as its parent in a tree
+func renderTreeKeys(ent *model.Entity) []*model.Attribute {
+	keys := make([]*model.Attribute, 0, 1)
+	for _, attr := range ent.Attributes() {
+		if attr.DirectChild || attr.Referenced() != ent {
+			continue
+		}
+		if len(keys) > 0 && keys[0].Source != attr.Source {
+			continue
+		}
+		keys = append(keys, attr)
+	}
+	return keys
+}
+
 func renderHandlerName(ent *model.Entity) string {
 	return strcase.ToCamel(fmt.Sprintf("%s_handler", ent.Name))
 }
@@ -74,7 +209,7 @@ func renderPathValues(ent *model.Entity) string {
 
 	if len(primaries) > 1 {
 		for _, attr := range primaries {
-			if attr.Final.Parent.CompositePrimary() {
+			if attr.Referenced().CompositePrimary() {
 				s := fmt.Sprintf("%s/{%s}", strcase.ToKebab(attr.Name()), attr.Name())
 				parts = append(parts, s)
 				continue
@@ -82,7 +217,7 @@ func renderPathValues(ent *model.Entity) string {
 
 			// if not composite we can remove redundant pattern to improve clarity
 			// 'foo-id/{foo_id}' -> '/foo/{foo_id}'
-			redundantSuffix := fmt.Sprintf("-%s", attr.Final.Name)
+			redundantSuffix := fmt.Sprintf("-%s", attr.ReferencedName())
 			s2, _ := strings.CutSuffix(strcase.ToKebab(attr.Name()), redundantSuffix)
 			s := fmt.Sprintf("%s/{%s}", s2, attr.Name())
 			parts = append(parts, s)
@@ -96,83 +231,261 @@ func renderPathValues(ent *model.Entity) string {
 	return strings.Join(parts, "/")
 }
 
+// renderFilterable is true if the list of an entity can be filtered by an attribute,
+// as the handler collects it from a request
+func renderFilterable(attr *model.Attribute) bool {
+	if attr.Attribute.HasErr() || attr.Final.Array {
+		return false
+	}
+	switch attr.Final.Kind {
+	case model.AttrKindJSON, model.AttrKindBytes:
+		return false
+	default:
+		return true
+	}
+}
+
+// renderIncludes are the relations of an entity the handler can read
+// along with it, the first of any with the same name being the one read
+func renderIncludes(ent *model.Entity) []model.Relation {
+	relations := make([]model.Relation, 0, len(ent.Relations))
+	seen := make(map[string]bool, len(ent.Relations))
+	for _, rel := range ent.Relations {
+		if seen[rel.HasName()] {
+			continue
+		}
+		seen[rel.HasName()] = true
+		relations = append(relations, rel)
+	}
+	return relations
+}
+
 func renderGoKind(attr *model.Attribute) string {
 	k := attr.Final.Kind
 
-	if k == model.AttrKindSerial && !attr.DirectChild {
-		k = model.AttrKindInt
+	if !attr.DirectChild {
+		k = k.Base()
+	}
+
+	if attr.Final.Array {
+		if s, ok := _goArrayKind[k]; ok {
+			return "[]" + s
+		}
+		return "[]string"
+	}
+
+	// an enum type lives in the package of its schema
+	if k == model.AttrKindEnum && !attr.ChangedSchema {
+		return renderGoEnumName(attr.Final)
 	}
 
 	s := _goKind[k]
 	return s
 }
 
+// renderGoEnumName is the name of the string type made for an enum attribute
+func renderGoEnumName(attr *model.AttributeRaw) string {
+	return renderPascalUA(fmt.Sprintf("%s_%s", attr.Parent.Name, attr.Name))
+}
+
+// renderGoEnumConst is the name of the constant for a value of an enum attribute
+func renderGoEnumConst(attr *model.AttributeRaw, value string) string {
+	return renderGoEnumName(attr) + renderPascalUA(internal.Normalize(value))
+}
+
+// renderGoNullable is true if a column may be null and its go kind has
+// no null of its own, as a slice or a raw message already scan nil
+func renderGoNullable(attr *model.Attribute) bool {
+	if renderIsNotNull(attr) || attr.Source.Primary || attr.Final.Array {
+		return false
+	}
+	switch attr.Final.Kind {
+	case model.AttrKindJSON, model.AttrKindBytes:
+		return false
+	default:
+		return true
+	}
+}
+
+// renderJSONNullable is true if a field of a record may be null once
+// encoded as json, as a nullable field or an empty slice is
+func renderJSONNullable(attr *model.Attribute) bool {
+	return !renderIsNotNull(attr) && !attr.Source.Primary
+}
+
+// renderGoField is the kind of a struct field, able to be null if need be
+func renderGoField(attr *model.Attribute, opts GoOptions) string {
+	s := renderGoKind(attr)
+	if !renderGoNullable(attr) {
+		return s
+	}
+	switch opts.Nullable {
+	case GoNullableGeneric:
+		return fmt.Sprintf("nullable.Null[%s]", s)
+	default:
+		return "*" + s
+	}
+}
+
+// renderGoValue is the value of a struct field for validation,
+// assumed to be present if it is nullable
+func renderGoValue(attr *model.Attribute, opts GoOptions) string {
+	s := fmt.Sprintf("%s.%s", renderCamelUA(attr.Source.Parent.Name), renderPascalUA(attr.Name()))
+	if !renderGoNullable(attr) {
+		return s
+	}
+	switch opts.Nullable {
+	case GoNullableGeneric:
+		return s + ".V"
+	default:
+		return fmt.Sprintf("(*%s)", s)
+	}
+}
+
+// renderGoHasValue is the condition a nullable struct field is not null
+func renderGoHasValue(attr *model.Attribute, opts GoOptions) string {
+	s := fmt.Sprintf("%s.%s", renderCamelUA(attr.Source.Parent.Name), renderPascalUA(attr.Name()))
+	switch opts.Nullable {
+	case GoNullableGeneric:
+		return s + ".Valid"
+	default:
+		return s + " != nil"
+	}
+}
+
 func renderGoEmptyValue(attr *model.Attribute) string {
 	k := attr.Final.Kind
 
-	if k == model.AttrKindSerial && !attr.DirectChild {
-		k = model.AttrKindInt
+	if !attr.DirectChild {
+		k = k.Base()
 	}
 
 	s := _goZeroVue[k]
 	return s
 }
 
-// renderCast is used after placeholder args to cast if needed
-func renderCast(attr *model.Attribute) string {
+// renderCast is used after placeholder args to cast if needed,
+// sqlite is loosely typed so it never needs one
+func renderCast(attr *model.Attribute, opts GoOptions) string {
+	if opts.Dialect == GoDialectSQLite {
+		return ""
+	}
 	switch attr.Final.Kind {
 	case model.AttrKindBit:
 		i, _ := strconv.Atoi(attr.Final.Max.String)
+		if attr.Final.Array {
+			return fmt.Sprintf("::bit(%d)[]", i)
+		}
 		return fmt.Sprintf("::bit(%d)", i)
 	default:
 		return ""
 	}
 }
 
+// renderGoArg is the field of a receiver passed as a query arg,
+// wrapped for the driver if it is an array
+func renderGoArg(attr *model.Attribute, receiver string) string {
+	s := fmt.Sprintf("%s.%s", receiver, renderPascalUA(attr.Name()))
+	if attr.Final.Array {
+		return fmt.Sprintf("pq.Array(%s)", s)
+	}
+	return s
+}
+
+// renderGoScanDest is the field of a receiver a row is scanned into,
+// wrapped for the driver if it is an array
+func renderGoScanDest(attr *model.Attribute, receiver string) string {
+	s := fmt.Sprintf("&%s.%s", receiver, renderPascalUA(attr.Name()))
+	if attr.Final.Array {
+		return fmt.Sprintf("pq.Array(%s)", s)
+	}
+	return s
+}
+
 func packageName(s string) string {
 	s = strcase.ToSnake(s)
 	return strings.ReplaceAll(s, "_", "")
 }
 
 // GoStructs generates golang structs
-func GoStructs(schemas []*model.Schema) (map[FileName]string, error) {
-	var err error
-
-	var rootTmpl = func() template.Template {
-		tmpl := template.Template{}
-
-		tmpl.Funcs(template.FuncMap{
-			"notLast": func(i int, arr []*model.Attribute) bool {
-				return i != len(arr)-1
-			},
-			"renderCamel":  renderCamelUA,
-			"renderPascal": renderPascalUA,
-			"renderKebab":  renderKebab,
-
-			"renderGoErrs":       renderGoErrs,
-			"renderGoKind":       renderGoKind,
-			"renderGoEmptyValue": renderGoEmptyValue,
-			"renderCast":         renderCast,
-
-			"renderPlusOne":            renderPlusOne,
-			"renderPrimaryPlaceholder": renderPrimaryPlaceholder,
-
-			"renderStoreName":   renderStoreName,
-			"renderHandlerName": renderHandlerName,
-			"renderPathValues":  renderPathValues,
-		})
-		return tmpl
+func GoStructs(schemas []*model.Schema, opts GoOptions) (map[FileName]string, error) {
+	funcs := template.FuncMap{
+		"notLast": func(i int, arr []*model.Attribute) bool {
+			return i != len(arr)-1
+		},
+		"renderCamel":  renderCamelUA,
+		"renderPascal": renderPascalUA,
+		"renderKebab":  renderKebab,
+
+		"renderGoErrs":       renderGoErrs,
+		"renderGoKind":       renderGoKind,
+		"renderGoEmptyValue": renderGoEmptyValue,
+		"renderCast": func(attr *model.Attribute) string {
+			return renderCast(attr, opts)
+		},
+		"renderGoArg":      renderGoArg,
+		"renderGoScanDest": renderGoScanDest,
+
+		"renderPlusOne":            renderPlusOne,
+		"renderPrimaryPlaceholder": renderPrimaryPlaceholder,
+		"renderManagedSet":         renderManagedSet,
+		"renderPlaceholder": func(n int) string {
+			return renderPlaceholder(n, opts)
+		},
+		"renderTable": func(ent *model.Entity) string {
+			return renderTable(ent, opts)
+		},
+		"renderTableFrom": func(ent *model.Entity) string {
+			return renderTableFrom(ent, opts)
+		},
+		"renderJoinAs": renderJoinAs,
+		"renderJoinFrom": func(rel model.Relation) string {
+			return renderJoinFrom(rel, opts)
+		},
+		"renderTreeKeys": renderTreeKeys,
+		// usesSQLite is true if the store queries sqlite rather than postgres
+		"usesSQLite": func() bool {
+			return opts.Dialect == GoDialectSQLite
+		},
+
+		"renderStoreName":   renderStoreName,
+		"renderHandlerName": renderHandlerName,
+		"renderPathValues":  renderPathValues,
+
+		"renderGoEnumName":  renderGoEnumName,
+		"renderGoEnumConst": renderGoEnumConst,
+
+		"renderGoNullable": renderGoNullable,
+		"renderGoField": func(attr *model.Attribute) string {
+			return renderGoField(attr, opts)
+		},
+		"renderGoValue": func(attr *model.Attribute) string {
+			return renderGoValue(attr, opts)
+		},
+		"renderGoHasValue": func(attr *model.Attribute) string {
+			return renderGoHasValue(attr, opts)
+		},
+		// usesNullable is true if a schema needs the generic null package
+		"usesNullable": func(sch *model.Schema) bool {
+			if opts.Nullable != GoNullableGeneric {
+				return false
+			}
+			for _, ent := range sch.Entities {
+				if slices.ContainsFunc(ent.Attributes(), renderGoNullable) {
+					return true
+				}
+			}
+			return false
+		},
 	}
 
 	m := make(map[FileName]string, len(schemas))
 
+	tmpl, err := parseTemplates(funcs, "go/struct/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
 	for _, s := range schemas {
-		tmpl := rootTmpl()
-		_, err = tmpl.ParseGlob("templates/go/struct/**.tmpl")
-		if err != nil {
-			return nil, err
-		}
-
 		sb := strings.Builder{}
 		err = tmpl.ExecuteTemplate(&sb, "root.tmpl", s)
 		if err != nil {
@@ -182,13 +495,11 @@ func GoStructs(schemas []*model.Schema) (map[FileName]string, error) {
 		m[newFileName("internal/"+pn, fmt.Sprintf("%s.go", pn))] = sb.String()
 	}
 
+	tmpl, err = parseTemplates(funcs, "go/store/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
 	for _, s := range schemas {
-		tmpl := rootTmpl()
-		_, err = tmpl.ParseGlob("templates/go/store/**.tmpl")
-		if err != nil {
-			return nil, err
-		}
-
 		sb := strings.Builder{}
 		err = tmpl.ExecuteTemplate(&sb, "root.tmpl", s)
 		if err != nil {
@@ -197,39 +508,48 @@ func GoStructs(schemas []*model.Schema) (map[FileName]string, error) {
 		m[newFileName(packageName("internal/"+s.Name), "store.go")] = sb.String()
 	}
 
+	tmpl, err = parseTemplates(funcs, "go/handler/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
 	for _, s := range schemas {
-		tmpl := rootTmpl()
-		_, err = tmpl.ParseGlob("templates/go/handler/**.tmpl")
+		sb := strings.Builder{}
+		err = tmpl.ExecuteTemplate(&sb, "root.tmpl", s)
 		if err != nil {
 			return nil, err
 		}
+		m[newFileName(packageName("internal/"+s.Name), "handler.go")] = sb.String()
+	}
 
+	tmpl, err = parseTemplates(funcs, "go/valid/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range schemas {
 		sb := strings.Builder{}
 		err = tmpl.ExecuteTemplate(&sb, "root.tmpl", s)
 		if err != nil {
 			return nil, err
 		}
-		m[newFileName(packageName("internal/"+s.Name), "handler.go")] = sb.String()
+		m[newFileName(packageName("internal/"+s.Name), "valid.go")] = sb.String()
 	}
 
-	for _, s := range schemas {
-		tmpl := rootTmpl()
-		_, err = tmpl.ParseGlob("templates/go/valid/**.tmpl")
+	if opts.Nullable == GoNullableGeneric {
+		tmpl, err := parseTemplates(funcs, "go/nullable/*.tmpl")
 		if err != nil {
 			return nil, err
 		}
 
 		sb := strings.Builder{}
-		err = tmpl.ExecuteTemplate(&sb, "root.tmpl", s)
+		err = tmpl.ExecuteTemplate(&sb, "root.tmpl", nil)
 		if err != nil {
 			return nil, err
 		}
-		m[newFileName(packageName("internal/"+s.Name), "valid.go")] = sb.String()
+		m[newFileName(packageName("pkg/nullable"), "nullable.go")] = sb.String()
 	}
 
 	{
-		tmpl := rootTmpl()
-		_, err = tmpl.ParseGlob("templates/go/sqlsearch/**.tmpl")
+		tmpl, err := parseTemplates(funcs, "go/sqlsearch/*.tmpl")
 		if err != nil {
 			return nil, err
 		}
@@ -243,8 +563,7 @@ func GoStructs(schemas []*model.Schema) (map[FileName]string, error) {
 	}
 
 	{
-		tmpl := rootTmpl()
-		_, err = tmpl.ParseFiles("templates/go/app/main.tmpl")
+		tmpl, err := parseTemplates(funcs, "go/app/main.tmpl")
 		if err != nil {
 			return nil, err
 		}
@@ -257,8 +576,7 @@ func GoStructs(schemas []*model.Schema) (map[FileName]string, error) {
 	}
 
 	{
-		tmpl := rootTmpl()
-		_, err = tmpl.ParseFiles("templates/go/app/mod.tmpl")
+		tmpl, err := parseTemplates(funcs, "go/app/mod.tmpl")
 		if err != nil {
 			return nil, err
 		}
@@ -1,34 +1,497 @@
 package strgen
 
 import (
-	"os"
-	"path/filepath"
+	"fmt"
+	"maps"
+	"os/exec"
+	"strings"
 	"testing"
 
 	"github.com/Isaac799/devtoolbox/internal/strparse"
 )
 
 func TestGoStructs(t *testing.T) {
-	wd, _ := os.Getwd()
-	os.Chdir("..")
-	os.Chdir("..")
-	defer os.Chdir(wd)
-
 	schemas := strparse.Raw(testMockSchemas)
 
-	m, err := GoStructs(schemas)
+	m, err := GoStructs(schemas, GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testWriteFiles(t, m)
+}
+
+func TestGoStructsEnum(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen)
+
+	m, err := GoStructs(schemas, GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid := m[newFileName("internal/kitchen", "valid.go")]
+	for _, s := range []string{
+		"type IngredientStorage string",
+		`IngredientStorageFrozen IngredientStorage = "frozen"`,
+		"func (v IngredientStorage) Valid() bool",
+		"if !ingredient.Storage.Valid() {",
+	} {
+		if !strings.Contains(valid, s) {
+			t.Fatal("expected in valid.go: ", s)
+		}
+	}
+
+	structs := m[newFileName("internal/kitchen", "kitchen.go")]
+	if !strings.Contains(structs, "Storage IngredientStorage `json:\"storage\"`") {
+		t.Fatal("expected typed enum field")
+	}
+}
+
+func TestGoStructsUUID(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaShop)
+
+	m, err := GoStructs(schemas, GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := m[newFileName("internal/shop", "handler.go")]
+	if !strings.Contains(handler, "func (handler *ItemHandler) primaryFromRequest(r *http.Request) (string, int, error)") {
+		t.Fatal("expected mixed composite key to be parsed")
+	}
+	if !strings.Contains(handler, "if !validUUID(tenantID) {") {
+		t.Fatal("expected uuid path value to be checked")
+	}
+
+	structs := m[newFileName("internal/shop", "shop.go")]
+	if !strings.Contains(structs, "TenantID string `json:\"tenant_id\"`") {
+		t.Fatal("expected uuid reference as a string")
+	}
+}
+
+func TestGoStructsDocumentKinds(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaShop)
+
+	m, err := GoStructs(schemas, GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structs := m[newFileName("internal/shop", "shop.go")]
+	for _, s := range []string{
+		`"encoding/json"`,
+		"Bio *string `json:\"bio\"`",
+		"Settings json.RawMessage `json:\"settings\"`",
+		"Logo []byte `json:\"logo\"`",
+	} {
+		if !strings.Contains(structs, s) {
+			t.Fatal("expected in shop.go: ", s)
+		}
+	}
+	if strings.Contains(structs, `"time"`) {
+		t.Fatal("expected time to only be imported when used")
+	}
+
+	valid := m[newFileName("internal/shop", "valid.go")]
+	if !strings.Contains(valid, "if len((*tenant.Bio)) > 2000 {") {
+		t.Fatal("expected text length to be checked")
+	}
+}
+
+func TestGoStructsArray(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaShop)
+
+	m, err := GoStructs(schemas, GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structs := m[newFileName("internal/shop", "shop.go")]
+	if !strings.Contains(structs, "Tags []string `json:\"tags\"`") || !strings.Contains(structs, "Sizes []int64 `json:\"sizes\"`") {
+		t.Fatal("expected slice fields: ", structs)
+	}
+
+	store := m[newFileName("internal/shop", "store.go")]
+	for _, s := range []string{
+		`"github.com/lib/pq"`,
+		"pq.Array(&one.Tags)",
+		"pq.Array(item.Sizes)",
+	} {
+		if !strings.Contains(store, s) {
+			t.Fatal("expected in store.go: ", s)
+		}
+	}
+
+	valid := m[newFileName("internal/shop", "valid.go")]
+	if !strings.Contains(valid, "for _, v := range item.Sizes {") || !strings.Contains(valid, "if v > 12 {") {
+		t.Fatal("expected each element to be checked: ", valid)
+	}
+}
+
+func TestGoStructsNullable(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaShop)
+
+	m, err := GoStructs(schemas, GoOptions{Nullable: GoNullableGeneric})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	scope := filepath.Join("generated", "golang")
-	os.RemoveAll(scope)
+	structs := m[newFileName("internal/shop", "shop.go")]
+	for _, s := range []string{
+		`"example/pkg/nullable"`,
+		"Name string `json:\"name\"`",
+		"Bio nullable.Null[string] `json:\"bio\"`",
+		"Tags []string `json:\"tags\"`",
+	} {
+		if !strings.Contains(structs, s) {
+			t.Fatal("expected in shop.go: ", s)
+		}
+	}
+	if _, ok := m[newFileName("pkg/nullable", "nullable.go")]; !ok {
+		t.Fatal("expected the nullable package")
+	}
 
-	for k, v := range m {
-		dir := filepath.Join(scope, k.Path())
-		name := filepath.Join(scope, k.Full())
+	valid := m[newFileName("internal/shop", "valid.go")]
+	if !strings.Contains(valid, "if tenant.Bio.Valid {") || !strings.Contains(valid, "if len(tenant.Bio.V) > 2000 {") {
+		t.Fatal("expected a null value to be skipped: ", valid)
+	}
 
-		os.MkdirAll(dir, os.ModePerm)
-		os.WriteFile(name, []byte(v), os.ModePerm)
+	m, err = GoStructs(schemas, GoOptions{Nullable: GoNullablePointer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m[newFileName("pkg/nullable", "nullable.go")]; ok {
+		t.Fatal("expected no nullable package for pointers")
+	}
+	if !strings.Contains(m[newFileName("internal/shop", "valid.go")], "if tenant.Bio != nil {") {
+		t.Fatal("expected a nil pointer to be skipped")
+	}
+}
+
+func TestGoStructsSQLite(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen)
+
+	m, err := GoStructs(schemas, GoOptions{Dialect: GoDialectSQLite})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := m[newFileName("internal/kitchen", "store.go")]
+	for _, s := range []string{
+		"INSERT INTO kitchen_food (name) VALUES (?) RETURNING id",
+		"UPDATE kitchen_supplier SET k_1=?, k_2=?, name=? WHERE k_1=? AND k_2=?",
+		"FROM kitchen_ingredient AS ingredient",
+		"LIMIT ? OFFSET ?",
+		"args := append(placeholderValues, limit, offset)",
+	} {
+		if !strings.Contains(store, s) {
+			t.Fatal("expected in store.go: ", s)
+		}
+	}
+	if strings.Contains(store, "$1") || strings.Contains(store, "::bit") {
+		t.Fatal("expected no postgres placeholders or casts: ", store)
+	}
+
+	if strings.Contains(m[newFileName("internal/kitchen", "handler.go")], "::bit") {
+		t.Fatal("expected no casts in filters")
+	}
+	if !strings.Contains(m[newFileName("pkg/sqlsearch", "sqlsearch.go")], `return "?"`) {
+		t.Fatal("expected search placeholders for sqlite")
+	}
+	if !strings.Contains(m[newFileName("", "main.go")], `sql.Open("sqlite", connStr)`) {
+		t.Fatal("expected the sqlite driver")
 	}
+
+	m, err = GoStructs(schemas, GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(m[newFileName("internal/kitchen", "store.go")], "INSERT INTO kitchen.food (name) VALUES ($1) RETURNING id") {
+		t.Fatal("expected postgres by default")
+	}
+}
+
+func TestGoStructsTree(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaTree)
+
+	m, err := GoStructs(schemas, GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structs := m[newFileName("internal/tree", "tree.go")]
+	for _, s := range []string{
+		"ParentID *int `json:\"parent_id\"`",
+		"Parent *Category `json:\"parent\"`",
+		"Children []Category `json:\"children\"`",
+	} {
+		if !strings.Contains(structs, s) {
+			t.Fatal("expected in tree.go: ", s)
+		}
+	}
+
+	store := m[newFileName("internal/tree", "store.go")]
+	for _, s := range []string{
+		"func (store *CategoryStore) ReadChildren(ID int, depth int) ([]Category, error)",
+		"func (store *CategoryStore) ReadAncestors(ID int, depth int) ([]Category, error)",
+		"LEFT JOIN tree.category AS parent ON category.parent_id = parent.id",
+		"LEFT JOIN tree.category AS children ON children.parent_id = category.id",
+		"JOIN tree ON category.parent_id = tree.id",
+		"JOIN tree ON tree.parent_id = category.id",
+		"JOIN tree ON employee.manager_k_1 = tree.k_1 AND employee.manager_k_2 = tree.k_2",
+		"WHERE child.k_1=$1 AND child.k_2=$2",
+		"WHERE tree.tree_depth < $3",
+		"hasParentID := joinNull(&has.ParentID)",
+		"one.Parent = &has",
+	} {
+		if !strings.Contains(store, s) {
+			t.Fatal("expected in store.go: ", s)
+		}
+	}
+
+	handler := m[newFileName("internal/tree", "handler.go")]
+	if !strings.Contains(handler, `case "children":`) {
+		t.Fatal("expected children to be included")
+	}
+
+	m, err = GoStructs(strparse.Raw(testMockSchemaKitchen), GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(m[newFileName("internal/kitchen", "store.go")], "ReadChildren") {
+		t.Fatal("expected no tree of an entity without a reference to itself")
+	}
+}
+
+func TestGoStructsEntityOptions(t *testing.T) {
+	schemas := strparse.Raw(strings.NewReplacer(
+		"## Food", "## Food with timestamps, soft delete, versioned",
+		"## Recipe", "## Recipe with soft delete",
+		"## Ingredient", "## Ingredient with soft delete",
+	).Replace(testMockSchemaKitchen))
+
+	m, err := GoStructs(schemas, GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store := m[newFileName("internal/kitchen", "store.go")]
+	for _, s := range []string{
+		`var ErrConflict = errors.New(`,
+		"INSERT INTO kitchen.food (name) VALUES ($1) RETURNING id",
+		"FROM kitchen.food WHERE id=$1 AND deleted_at IS NULL",
+		`placeholder = "WHERE deleted_at IS NULL"`,
+		`placeholder = fmt.Sprintf("WHERE (%s) AND deleted_at IS NULL", strings.TrimPrefix(placeholder, "WHERE "))`,
+		"UPDATE kitchen.food SET name=$1, updated_at=current_timestamp, version=version + 1 WHERE id=$2 AND deleted_at IS NULL AND version=$3 RETURNING version",
+		"store.db.QueryRow(q, food.Name, ID, food.Version).Scan(&food.Version)",
+		"LEFT JOIN (kitchen.recipe JOIN kitchen.food ON recipe.food_id = food.id AND recipe.deleted_at IS NULL AND food.deleted_at IS NULL) ON recipe.ingredient_id = ingredient.id",
+		"LEFT JOIN kitchen.ingredient ON ingredient.supplier_k_1 = supplier.k_1 AND ingredient.supplier_k_2 = supplier.k_2 AND ingredient.deleted_at IS NULL",
+		"return 0, ErrConflict",
+		"UPDATE kitchen.food SET deleted_at=current_timestamp WHERE id=$1 AND deleted_at IS NULL",
+	} {
+		if !strings.Contains(store, s) {
+			t.Fatal("expected in store.go: ", s)
+		}
+	}
+	if strings.Contains(store, "DELETE FROM kitchen.food") {
+		t.Fatal("expected a soft delete to be an update")
+	}
+	if !strings.Contains(store, "DELETE FROM kitchen.supplier") {
+		t.Fatal("expected an entity without the option to be deleted")
+	}
+
+	if !strings.Contains(m[newFileName("internal/kitchen", "handler.go")], "http.StatusConflict") {
+		t.Fatal("expected a conflict to be a 409")
+	}
+
+	m, err = GoStructs(strparse.Raw(testMockSchemaKitchen), GoOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(m[newFileName("internal/kitchen", "store.go")], "ErrConflict") {
+		t.Fatal("expected no conflict without a versioned entity")
+	}
+}
+
+// testGoStoreOpen is a test helper of a generated package, opening a
+// database in memory setup as the sqlite target writes it
+const testGoStoreOpen = `package %s
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func testOpen(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	setups, _ := filepath.Glob("../../migrations/sqlite/*.sql")
+	for _, setup := range setups {
+		b, err := os.ReadFile(setup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(b)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+`
+
+// testGoRun generates the go and sqlite of a schema, adds the tests to the
+// package of the schema, and runs them against a database in memory. As it
+// builds a project, it is skipped when short or without its modules.
+func testGoRun(t *testing.T, s string, tests map[string]string) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds a generated project")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip(err)
+	}
+
+	schemas := strparse.Raw(s)
+	m, err := GoStructs(schemas, GoOptions{Dialect: GoDialectSQLite})
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup, err := SQLiteSetup(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maps.Copy(m, setup)
+
+	pn := packageName(schemas[0].Name)
+	m[newFileName("internal/"+pn, "open_test.go")] = fmt.Sprintf(testGoStoreOpen, pn)
+	for name, v := range tests {
+		m[newFileName("internal/"+pn, name)] = v
+	}
+	dir := testWriteFiles(t, m)
+
+	goCmd := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("go", args...)
+		cmd.Dir = dir
+		return cmd.CombinedOutput()
+	}
+	if out, err := goCmd("mod", "tidy"); err != nil {
+		t.Skip("modules of the generated project are not available: ", string(out))
+	}
+	if out, err := goCmd("test", "./internal/"+pn); err != nil {
+		t.Fatal(string(out))
+	}
+}
+
+// testGoTreeStore reads the relations of a root and a leaf, whose
+// joins are null for the parent of the root and children of the leaf
+const testGoTreeStore = `package tree
+
+import "testing"
+
+func TestTreeStore(t *testing.T) {
+	store := NewCategoryStore(testOpen(t))
+
+	root := Category{Name: "root"}
+	if err := store.Create(&root); err != nil {
+		t.Fatal(err)
+	}
+	leaf := Category{Name: "leaf", ParentID: &root.ID}
+	if err := store.Create(&leaf); err != nil {
+		t.Fatal(err)
+	}
+
+	withParent, err := store.ReadWithParent(root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withParent.Parent != nil || withParent.Name != "root" {
+		t.Fatal("expected a root without a parent: ", withParent.Parent)
+	}
+	withParent, err = store.ReadWithParent(leaf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withParent.Parent == nil || withParent.Parent.ID != root.ID {
+		t.Fatal("expected the parent of a leaf: ", withParent.Parent)
+	}
+
+	withChildren, err := store.ReadWithChildren(leaf.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withChildren.Children) != 0 || withChildren.Name != "leaf" {
+		t.Fatal("expected a leaf without children: ", withChildren.Children)
+	}
+	withChildren, err = store.ReadWithChildren(root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(withChildren.Children) != 1 || withChildren.Children[0].ID != leaf.ID {
+		t.Fatal("expected the children of a root: ", withChildren.Children)
+	}
+}
+`
+
+func TestGoStoreTree(t *testing.T) {
+	testGoRun(t, testMockSchemaTree, map[string]string{"tree_test.go": testGoTreeStore})
+}
+
+const testMockSchemaSoftDelete = `# Shop
+
+## Item with soft delete
+- id          as ++
+- name        as str  with required, ..30
+- price       as int`
+
+// testGoSoftDeleteStore searches with terms that are of an or, which
+// are kept apart from leaving out what is deleted
+const testGoSoftDeleteStore = `package shop
+
+import (
+	"testing"
+
+	"example/pkg/sqlsearch"
+)
+
+func TestSoftDeleteStore(t *testing.T) {
+	store := NewItemStore(testOpen(t))
+
+	price := 1
+	for _, name := range []string{"a", "b", "c"} {
+		if err := store.Create(&Item{Name: name, Price: &price}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	many, err := store.ReadMany(10, 0, nil)
+	if err != nil || len(many) != 3 {
+		t.Fatal("expected every item: ", many, err)
+	}
+	b := many[1]
+	if n, err := store.Delete(b.ID); err != nil || n != 1 {
+		t.Fatal("expected b deleted: ", n, err)
+	}
+
+	many, err = store.ReadMany(10, 0, []sqlsearch.WhereClauseItem{
+		{Column: "price", Value: 0, Operator: sqlsearch.GreaterThan},
+		{Column: "name = 'b' OR name", Value: "a", Operator: sqlsearch.Equal},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(many) != 1 || many[0].Name != "a" {
+		t.Fatal("expected what is deleted left out of a search: ", many)
+	}
+}
+`
+
+func TestGoStoreSoftDelete(t *testing.T) {
+	testGoRun(t, testMockSchemaSoftDelete, map[string]string{"item_test.go": testGoSoftDeleteStore})
 }
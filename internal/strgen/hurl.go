@@ -1,6 +1,7 @@
 package strgen
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"text/template"
@@ -25,41 +26,156 @@ var _hurlSeedValue = map[model.AttrKind]string{
 	model.AttrKindReal:      "1.2",
 	model.AttrKindDecimal:   "1.2",
 	model.AttrKindMoney:     "1.2",
+	model.AttrKindEnum:      `""`,
+
+	model.AttrKindUUID:          `"0b9e6a8c-2f4d-4c1e-9a3b-7d5f1e2c4a6b"`,
+	model.AttrKindGeneratedUUID: `"0b9e6a8c-2f4d-4c1e-9a3b-7d5f1e2c4a6b"`,
+	model.AttrKindText:          `"lorem ipsum"`,
+	model.AttrKindJSON:          `{"foo": "bar"}`,
+	model.AttrKindBytes:         `"Zm9v"`,
+}
+
+// _hurlArraySeedValue overrides the seed of an element for kinds
+// held as text when in an array, so they match the parsed layout
+var _hurlArraySeedValue = map[model.AttrKind]string{
+	model.AttrKindChar:      `"a"`,
+	model.AttrKindDate:      `"2025-11-08"`,
+	model.AttrKindTime:      `"21:24:52"`,
+	model.AttrKindTimestamp: `"2025-11-08 21:24:52"`,
 }
 
 func renderSeedValue(attr *model.Attribute) string {
 	k := attr.Final.Kind
 
-	if k == model.AttrKindSerial && !attr.DirectChild {
-		k = model.AttrKindInt
+	if !attr.DirectChild {
+		k = k.Base()
+	}
+
+	// a version is as a record is made, for an update of it to not conflict
+	if attr.Source.Managed && attr.Source.Name == model.AttrNameVersion {
+		return attr.Source.DefaultValue
 	}
 
-	s := _hurlSeedValue[k]
+	// a reference that may be null is, as what it is to may not exist. One to
+	// its own entity has nothing to be to before the first record is made.
+	if !attr.DirectChild && (!attr.Source.Required.Bool || attr.Source.ReferenceTo == attr.Source.Parent) {
+		return "null"
+	}
+
+	var s string
+
+	// only a listed value is legal for an enum
+	if k == model.AttrKindEnum && len(attr.Final.EnumValues) > 0 {
+		b, _ := json.Marshal(attr.Final.EnumValues[0])
+		s = string(b)
+	} else {
+		s = _hurlSeedValue[k]
+	}
+
+	if attr.Final.Array {
+		if v, ok := _hurlArraySeedValue[k]; ok {
+			s = v
+		}
+		return fmt.Sprintf("[%s]", s)
+	}
 	return s
 }
 
+// renderHurlPredicate is how a captured primary key is asserted,
+// only numbers can be checked for being above zero
+func renderHurlPredicate(attr *model.Attribute) string {
+	switch attr.Final.Kind.Base() {
+	case model.AttrKindInt:
+		return "> 0"
+	default:
+		return "exists"
+	}
+}
+
 func renderHurlPathValues(ent *model.Entity) string {
+	return hurlPathValues(ent, "")
+}
+
+// hurlCascade is a record made to reference the one a test deletes,
+// which is gone after since its reference cascades
+type hurlCascade struct {
+	Parent *model.Entity
+	Child  *model.Entity
+}
+
+// hurlCascades are the entities with a reference to an entity that cascades
+// as it is deleted, and that can be read to check they are gone
+func hurlCascades(schemas []*model.Schema, ent *model.Entity) []hurlCascade {
+	cascades := make([]hurlCascade, 0)
+	// a record kept when deleted has nothing to cascade
+	if ent.SoftDelete {
+		return cascades
+	}
+	for _, s := range schemas {
+		for _, child := range s.Entities {
+			if !child.HasPrimary() {
+				continue
+			}
+			for _, attr := range child.RawAttributes {
+				if attr.ReferenceTo == ent && attr.OnDelete == model.RefActionCascade && !attr.HasErr() {
+					cascades = append(cascades, hurlCascade{Parent: ent, Child: child})
+					break
+				}
+			}
+		}
+	}
+	return cascades
+}
+
+// renderHurlCascadeVar is the name a key of a cascaded record is captured as,
+// apart from the keys of the record deleted
+func renderHurlCascadeVar(child *model.Entity, name string) string {
+	return fmt.Sprintf("cascade_%s_%s", child.Name, name)
+}
+
+// renderHurlCascadePathValues is the path of a cascaded record, by its captured keys
+func renderHurlCascadePathValues(child *model.Entity) string {
+	return hurlPathValues(child, renderHurlCascadeVar(child, ""))
+}
+
+// renderHurlCascadeValue is the value of an attribute of a cascaded record, the
+// key captured of the record deleted if that is what its reference is to
+func renderHurlCascadeValue(attr *model.Attribute, parent *model.Entity) string {
+	s := renderSeedValue(attr)
+	if attr.DirectChild || attr.Source.ReferenceTo != parent || attr.Source.OnDelete != model.RefActionCascade {
+		return s
+	}
+	v := fmt.Sprintf("{{%s}}", attr.ReferencedName())
+	if strings.HasPrefix(s, `"`) {
+		return fmt.Sprintf("%q", v)
+	}
+	return v
+}
+
+// hurlPathValues is the path of a record by the variables its keys are
+// captured as, each named with a prefix
+func hurlPathValues(ent *model.Entity, prefix string) string {
 	parts := []string{}
 	primaries := ent.Primary()
 
 	if len(primaries) > 1 {
 		for _, attr := range primaries {
-			if attr.Final.Parent.CompositePrimary() {
-				s := fmt.Sprintf("%s/{{%s}}", strcase.ToKebab(attr.Name()), attr.Name())
+			if attr.Referenced().CompositePrimary() {
+				s := fmt.Sprintf("%s/{{%s%s}}", strcase.ToKebab(attr.Name()), prefix, attr.Name())
 				parts = append(parts, s)
 				continue
 			}
 
 			// if not composite we can remove redundant pattern to improve clarity
 			// 'foo-id/{foo_id}' -> '/foo/{foo_id}'
-			redundantSuffix := fmt.Sprintf("-%s", attr.Final.Name)
+			redundantSuffix := fmt.Sprintf("-%s", attr.ReferencedName())
 			s2, _ := strings.CutSuffix(strcase.ToKebab(attr.Name()), redundantSuffix)
-			s := fmt.Sprintf("%s/{{%s}}", s2, attr.Name())
+			s := fmt.Sprintf("%s/{{%s%s}}", s2, prefix, attr.Name())
 			parts = append(parts, s)
 		}
 	} else {
 		for _, attr := range primaries {
-			s := fmt.Sprintf("{{%s}}", strcase.ToSnake(attr.Name()))
+			s := fmt.Sprintf("{{%s%s}}", prefix, strcase.ToSnake(attr.Name()))
 			parts = append(parts, s)
 		}
 	}
@@ -68,30 +184,28 @@ func renderHurlPathValues(ent *model.Entity) string {
 
 // HurlTests generates hurl tests
 func HurlTests(schemas []*model.Schema) (map[FileName]string, error) {
-	var err error
-
-	var rootTmpl = func() template.Template {
-		tmpl := template.Template{}
-
-		tmpl.Funcs(template.FuncMap{
-			"renderHurlPathValues": renderHurlPathValues,
-			"renderKebab":          renderKebab,
-			"renderSeedValue":      renderSeedValue,
-			"notLast": func(i int, arr []*model.Attribute) bool {
-				return i != len(arr)-1
-			},
-		})
-		return tmpl
+	tmpl, err := parseTemplates(template.FuncMap{
+		"renderHurlPathValues": renderHurlPathValues,
+		"renderKebab":          renderKebab,
+		"renderSeedValue":      renderSeedValue,
+		"renderHurlPredicate":  renderHurlPredicate,
+		"renderHurlCascades": func(ent *model.Entity) []hurlCascade {
+			return hurlCascades(schemas, ent)
+		},
+		"renderHurlCascadeVar":        renderHurlCascadeVar,
+		"renderHurlCascadePathValues": renderHurlCascadePathValues,
+		"renderHurlCascadeValue":      renderHurlCascadeValue,
+		"notLast": func(i int, arr []*model.Attribute) bool {
+			return i != len(arr)-1
+		},
+	}, "hurl/*.tmpl")
+	if err != nil {
+		return nil, err
 	}
 
 	m := make(map[FileName]string, len(schemas))
 
 	for _, s := range schemas {
-		tmpl := rootTmpl()
-		_, err = tmpl.ParseGlob("templates/hurl/**.tmpl")
-		if err != nil {
-			return nil, err
-		}
 		for _, ent := range s.Entities {
 			sb := strings.Builder{}
 			err = tmpl.ExecuteTemplate(&sb, "root.tmpl", ent)
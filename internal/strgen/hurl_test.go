@@ -1,19 +1,13 @@
 package strgen
 
 import (
-	"os"
-	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/Isaac799/devtoolbox/internal/strparse"
 )
 
 func TestHurlTests(t *testing.T) {
-	wd, _ := os.Getwd()
-	os.Chdir("..")
-	os.Chdir("..")
-	defer os.Chdir(wd)
-
 	schemas := strparse.Raw(testMockSchemas)
 
 	m, err := HurlTests(schemas)
@@ -21,14 +15,117 @@ func TestHurlTests(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	scope := filepath.Join("generated", "hurl")
-	os.RemoveAll(scope)
+	testWriteFiles(t, m)
+}
+
+func TestHurlEnumSeed(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen)
+
+	m, err := HurlTests(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := m[newFileName("tests", "kitchen.ingredient.hurl")]
+	if !strings.Contains(v, `"storage": "dry"`) {
+		t.Fatal("expected a legal enum seed: ", v)
+	}
+}
+
+func TestHurlUUIDAssert(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaShop)
+
+	m, err := HurlTests(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := m[newFileName("tests", "shop.item.hurl")]
+	if !strings.Contains(v, `variable "tenant_id" exists`) || !strings.Contains(v, `variable "seq" > 0`) {
+		t.Fatal("expected assert to suit the kind: ", v)
+	}
+}
 
-	for k, v := range m {
-		dir := filepath.Join(scope, k.Path())
-		name := filepath.Join(scope, k.Full())
+func TestHurlArraySeed(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaShop)
 
-		os.MkdirAll(dir, os.ModePerm)
-		os.WriteFile(name, []byte(v), os.ModePerm)
+	m, err := HurlTests(schemas)
+	if err != nil {
+		t.Fatal(err)
 	}
+
+	v := m[newFileName("tests", "shop.item.hurl")]
+	if !strings.Contains(v, `"tags": ["foo"]`) || !strings.Contains(v, `"sizes": [1]`) {
+		t.Fatal("expected a json array seed: ", v)
+	}
+}
+
+func TestHurlCascade(t *testing.T) {
+	schemas := strparse.Raw(strings.NewReplacer(
+		"- @food               with required, primary", "- @food               with required, primary, cascade",
+	).Replace(testMockSchemaKitchen))
+
+	m, err := HurlTests(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := m[newFileName("tests", "kitchen.food.hurl")]
+	expectInOrder(t, v,
+		"POST http://localhost:8080/api/recipe",
+		`"food_id": {{id}}`,
+		`cascade_recipe_food_id: jsonpath "$['food_id']"`,
+		"DELETE http://localhost:8080/api/food/{{id}}",
+		"GET http://localhost:8080/api/recipe/food/{{cascade_recipe_food_id}}/ingredient/{{cascade_recipe_ingredient_id}}\nHTTP 404",
+	)
+
+	if v := m[newFileName("tests", "kitchen.ingredient.hurl")]; strings.Contains(v, "cascade") {
+		t.Fatal("expected no cascade without the action: ", v)
+	}
+}
+
+func TestHurlEntityOptions(t *testing.T) {
+	schemas := strparse.Raw(strings.NewReplacer(
+		"## Food", "## Food with soft delete, versioned",
+		"- @food               with required, primary", "- @food               with required, primary, cascade",
+	).Replace(testMockSchemaKitchen))
+
+	m, err := HurlTests(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := m[newFileName("tests", "kitchen.food.hurl")]
+	expectInOrder(t, v,
+		`"version": 1`,
+		"PUT http://localhost:8080/api/food/{{id}}",
+		"HTTP 200",
+		"PUT http://localhost:8080/api/food/{{id}}",
+		"HTTP 409",
+		"DELETE http://localhost:8080/api/food/{{id}}",
+		"GET http://localhost:8080/api/food/{{id}}\nHTTP 404",
+	)
+	if strings.Contains(v, "cascade") {
+		t.Fatal("expected nothing to cascade from a record kept: ", v)
+	}
+}
+
+func TestHurlSelfReference(t *testing.T) {
+	m, err := HurlTests(strparse.Raw(testMockSchemaTree))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := m[newFileName("tests", "tree.category.hurl")]
+	expectInOrder(t, v,
+		"POST http://localhost:8080/api/category",
+		`"parent_id": null`,
+		"HTTP 200",
+	)
+
+	v = m[newFileName("tests", "tree.employee.hurl")]
+	expectInOrder(t, v,
+		`"manager_k_1": null`,
+		`"manager_k_2": null`,
+	)
 }
@@ -0,0 +1,300 @@
+package strgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// jsonKeyword is a keyword of a json schema and its value
+type jsonKeyword struct {
+	Key   string
+	Value any
+}
+
+// jsonSchema is a json schema as ordered keywords, so it is written
+// the same each time. It also orders the members of an object.
+type jsonSchema []jsonKeyword
+
+// String is the schema as a yaml flow mapping
+func (s jsonSchema) String() string {
+	parts := make([]string, 0, len(s))
+	for _, kw := range s {
+		var v string
+		switch x := kw.Value.(type) {
+		case jsonSchema:
+			v = x.String()
+		case json.Number:
+			v = x.String()
+		default:
+			b, _ := json.Marshal(x)
+			v = string(b)
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", kw.Key, v))
+	}
+	return fmt.Sprintf("{%s}", strings.Join(parts, ", "))
+}
+
+// MarshalJSON writes the keywords in order
+func (s jsonSchema) MarshalJSON() ([]byte, error) {
+	buf := bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, kw := range s {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, err := json.Marshal(kw.Key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(kw.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// _jsonTextPattern is the layout of a kind held as text, as it
+// is in an array or a path or query value, where it has no format
+var _jsonTextPattern = map[model.AttrKind]string{
+	model.AttrKindTimestamp: `^\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}$`,
+}
+
+// _jsonTextFormat is the format of a kind held as text
+var _jsonTextFormat = map[model.AttrKind]string{
+	model.AttrKindDate: "date",
+	model.AttrKindTime: "time",
+}
+
+// jsonSchemaValue is the schema of a single value of an attribute without
+// its bounds. As text it is how the value is written in an array, a
+// path, or a query, rather than how go encodes it in json.
+func jsonSchemaValue(attr *model.Attribute, asText bool) jsonSchema {
+	k := attr.Final.Kind
+
+	if !attr.DirectChild {
+		k = k.Base()
+	}
+
+	switch k {
+	case model.AttrKindSerial, model.AttrKindInt:
+		return jsonSchema{{"type", "integer"}}
+	case model.AttrKindFloat, model.AttrKindReal, model.AttrKindDecimal, model.AttrKindMoney:
+		return jsonSchema{{"type", "number"}}
+	case model.AttrKindBoolean:
+		return jsonSchema{{"type", "boolean"}}
+	case model.AttrKindBit:
+		return jsonSchema{{"type", "string"}, {"pattern", "^[0-9]+$"}}
+	case model.AttrKindDate, model.AttrKindTime, model.AttrKindTimestamp:
+		if !asText {
+			return jsonSchema{{"type", "string"}, {"format", "date-time"}}
+		}
+		if s, ok := _jsonTextFormat[k]; ok {
+			return jsonSchema{{"type", "string"}, {"format", s}}
+		}
+		return jsonSchema{{"type", "string"}, {"pattern", _jsonTextPattern[k]}}
+	case model.AttrKindEnum:
+		if len(attr.Final.EnumValues) == 0 {
+			return jsonSchema{{"type", "string"}}
+		}
+		return jsonSchema{{"type", "string"}, {"enum", attr.Final.EnumValues}}
+	case model.AttrKindUUID, model.AttrKindGeneratedUUID:
+		return jsonSchema{{"type", "string"}, {"format", "uuid"}}
+	case model.AttrKindBytes:
+		return jsonSchema{{"type", "string"}, {"contentEncoding", "base64"}}
+	case model.AttrKindJSON:
+		if asText {
+			return jsonSchema{{"type", "string"}}
+		}
+		// a document can be anything
+		return jsonSchema{}
+	default:
+		return jsonSchema{{"type", "string"}}
+	}
+}
+
+// jsonSchemaBounds are the keywords of the min and max of a value,
+// as they are checked when validating
+func jsonSchemaBounds(attr *model.Attribute) jsonSchema {
+	var minKey, maxKey string
+
+	switch attr.Final.Kind.Base() {
+	case model.AttrKindInt, model.AttrKindFloat, model.AttrKindReal, model.AttrKindDecimal, model.AttrKindMoney:
+		minKey, maxKey = "minimum", "maximum"
+	case model.AttrKindString, model.AttrKindText:
+		minKey, maxKey = "minLength", "maxLength"
+	case model.AttrKindChar:
+		// a char is exactly as long as its max
+		if attr.Final.Max.Valid {
+			return jsonSchema{{"minLength", json.Number(attr.Final.Max.String)}, {"maxLength", json.Number(attr.Final.Max.String)}}
+		}
+		return jsonSchema{}
+	default:
+		return jsonSchema{}
+	}
+
+	s := jsonSchema{}
+	if attr.Final.Min.Valid {
+		s = append(s, jsonKeyword{minKey, json.Number(attr.Final.Min.String)})
+	}
+	if attr.Final.Max.Valid {
+		s = append(s, jsonKeyword{maxKey, json.Number(attr.Final.Max.String)})
+	}
+	return s
+}
+
+// _jsonSchemaFormat is the format of a kind of time as it is modeled,
+// rather than as go encodes it
+var _jsonSchemaFormat = map[model.AttrKind]string{
+	model.AttrKindDate:      "date",
+	model.AttrKindTime:      "time",
+	model.AttrKindTimestamp: "date-time",
+}
+
+// jsonSchemaColumn is the schema of a column of a record as it is
+// validated. Formats override the format of a kind of time.
+func jsonSchemaColumn(attr *model.Attribute, formats map[model.AttrKind]string) jsonSchema {
+	var s jsonSchema
+
+	if attr.Final.Array {
+		items := append(jsonSchemaValue(attr, true), jsonSchemaBounds(attr)...)
+		s = jsonSchema{{"type", "array"}, {"items", items}}
+		if renderIsNotNull(attr) {
+			s = append(s, jsonKeyword{"minItems", 1})
+		}
+	} else {
+		s = jsonSchemaValue(attr, false)
+		if f, ok := formats[attr.Final.Kind]; ok {
+			s = jsonSchema{{"type", "string"}, {"format", f}}
+		}
+		s = append(s, jsonSchemaBounds(attr)...)
+		switch attr.Final.Kind {
+		case model.AttrKindString, model.AttrKindText:
+			// an empty string is taken as missing
+			if renderIsNotNull(attr) && !attr.Final.Min.Valid {
+				s = append(s, jsonKeyword{"minLength", 1})
+			}
+		}
+	}
+
+	if renderJSONNullable(attr) && len(s) > 0 && s[0].Key == "type" {
+		s[0].Value = []any{s[0].Value, "null"}
+	}
+	return s
+}
+
+// jsonSchemaReadOnly is true if the value of an attribute is generated,
+// not given by what creates a record
+func jsonSchemaReadOnly(attr *model.Attribute) bool {
+	return attr.DirectChild && attr.Final.Kind.Generated()
+}
+
+// jsonSchemaRequired are the names of attributes always in a record
+func jsonSchemaRequired(attrs []*model.Attribute) []string {
+	names := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		if attr.Attribute.HasErr() {
+			continue
+		}
+		if attr.Source.Primary || renderIsNotNull(attr) {
+			names = append(names, attr.Name())
+		}
+	}
+	return names
+}
+
+// jsonSchemaFile is where the document of an entity is written
+func jsonSchemaFile(ent *model.Entity) FileName {
+	return newFileName("jsonschema/"+packageName(ent.Parent.Name), fmt.Sprintf("%s.schema.json", ent.Name))
+}
+
+// jsonSchemaKeyRef is the key schema of the column a reference is to,
+// relative to the document of the entity referencing it
+func jsonSchemaKeyRef(attr *model.Attribute) string {
+	to := attr.Referenced()
+	file := jsonSchemaFile(to).fileName()
+	if to.Parent != attr.Source.Parent.Parent {
+		file = fmt.Sprintf("../%s/%s", packageName(to.Parent.Name), file)
+	}
+	return fmt.Sprintf("%s#/$defs/key/properties/%s", file, attr.ReferencedName())
+}
+
+// jsonSchemaDocument is the document of an entity. A column of a key is
+// defined as part of the key, so what references it can refer to it.
+func jsonSchemaDocument(ent *model.Entity) jsonSchema {
+	var (
+		attrs      = ent.Attributes()
+		properties = make(jsonSchema, 0, len(attrs))
+		keys       = make(jsonSchema, 0, 2)
+		keyNames   = make([]string, 0, 2)
+	)
+
+	for _, attr := range attrs {
+		if attr.Attribute.HasErr() {
+			continue
+		}
+
+		var s jsonSchema
+		if !attr.DirectChild {
+			s = jsonSchema{{"$ref", jsonSchemaKeyRef(attr)}}
+			if renderJSONNullable(attr) {
+				s = jsonSchema{{"anyOf", []jsonSchema{s, {{"type", "null"}}}}}
+			}
+		} else {
+			s = jsonSchemaColumn(attr, _jsonSchemaFormat)
+		}
+
+		if attr.Source.Primary {
+			keys = append(keys, jsonKeyword{attr.Name(), s})
+			keyNames = append(keyNames, attr.Name())
+			s = jsonSchema{{"$ref", "#/$defs/key/properties/" + attr.Name()}}
+		}
+		// only a key of its own is read only, not a reference to it
+		if jsonSchemaReadOnly(attr) {
+			s = append(s, jsonKeyword{"readOnly", true})
+		}
+		properties = append(properties, jsonKeyword{attr.Name(), s})
+	}
+
+	doc := jsonSchema{
+		{"$schema", "https://json-schema.org/draft/2020-12/schema"},
+		{"title", fmt.Sprintf("%s.%s", ent.Parent.Name, ent.Name)},
+		{"type", "object"},
+		{"properties", properties},
+		{"required", jsonSchemaRequired(attrs)},
+	}
+	if len(keys) > 0 {
+		key := jsonSchema{
+			{"type", "object"},
+			{"properties", keys},
+			{"required", keyNames},
+		}
+		doc = append(doc, jsonKeyword{"$defs", jsonSchema{{"key", key}}})
+	}
+	return doc
+}
+
+// JSONSchema generates a json schema document of the record of each entity
+func JSONSchema(schemas []*model.Schema) (map[FileName]string, error) {
+	m := make(map[FileName]string, 10)
+
+	for _, s := range schemas {
+		for _, ent := range s.Entities {
+			b, err := json.MarshalIndent(jsonSchemaDocument(ent), "", "  ")
+			if err != nil {
+				return nil, err
+			}
+			m[jsonSchemaFile(ent)] = string(b) + "\n"
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,104 @@
+package strgen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Isaac799/devtoolbox/internal/strparse"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemas)
+
+	m, err := JSONSchema(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testWriteFiles(t, m)
+}
+
+// testJSONDocument decodes a generated document to inspect it
+func testJSONDocument(t *testing.T, m map[FileName]string, name FileName) map[string]any {
+	t.Helper()
+	v, ok := m[name]
+	if !ok {
+		t.Fatal("expected document: ", name)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(v), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestJSONSchemaKeywords(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen + "\n" + testMockSchemaRestaurant + "\n" + testMockSchemaShop)
+
+	m, err := JSONSchema(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ingredient := testJSONDocument(t, m, newFileName("jsonschema/kitchen", "ingredient.schema.json"))
+	if ingredient["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
+		t.Fatal("expected draft 2020-12: ", ingredient["$schema"])
+	}
+	properties := ingredient["properties"].(map[string]any)
+
+	name := properties["name"].(map[string]any)
+	if name["minLength"] != 3.0 || name["maxLength"] != 30.0 {
+		t.Fatal("expected length of a string: ", name)
+	}
+	if eol := properties["eol"].(map[string]any); eol["format"] != "date" {
+		t.Fatal("expected date format: ", eol)
+	}
+	required := ingredient["required"].([]any)
+	if len(required) != 6 || required[1] != "name" {
+		t.Fatal("unexpected required: ", required)
+	}
+
+	customer := testJSONDocument(t, m, newFileName("jsonschema/restaurant", "customer.schema.json"))
+	visit := customer["properties"].(map[string]any)["last_visit"].(map[string]any)
+	if visit["format"] != "date-time" {
+		t.Fatal("expected timestamp as date-time: ", visit)
+	}
+
+	item := testJSONDocument(t, m, newFileName("jsonschema/shop", "item.schema.json"))
+	sizes := item["properties"].(map[string]any)["sizes"].(map[string]any)
+	items := sizes["items"].(map[string]any)
+	if items["minimum"] != 1.0 || items["maximum"] != 12.0 {
+		t.Fatal("expected range of an element: ", sizes)
+	}
+}
+
+func TestJSONSchemaReference(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen + "\n" + testMockSchemaRestaurant + "\n" + testMockSchemaFoo)
+
+	m, err := JSONSchema(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ingredient := testJSONDocument(t, m, newFileName("jsonschema/kitchen", "ingredient.schema.json"))
+	k1 := ingredient["properties"].(map[string]any)["supplier_k_1"].(map[string]any)
+	if k1["$ref"] != "supplier.schema.json#/$defs/key/properties/k_1" {
+		t.Fatal("expected ref to the key of supplier: ", k1)
+	}
+
+	supplier := testJSONDocument(t, m, newFileName("jsonschema/kitchen", "supplier.schema.json"))
+	key := supplier["$defs"].(map[string]any)["key"].(map[string]any)["properties"].(map[string]any)
+	if _, ok := key["k_1"].(map[string]any)["readOnly"]; ok {
+		t.Fatal("expected a key referenced not to be read only")
+	}
+	if own := supplier["properties"].(map[string]any)["k_1"].(map[string]any); own["readOnly"] != true {
+		t.Fatal("expected a serial key to be read only: ", own)
+	}
+
+	order := testJSONDocument(t, m, newFileName("jsonschema/foo", "order.schema.json"))
+	co := order["properties"].(map[string]any)["co_id"].(map[string]any)
+	anyOf := co["anyOf"].([]any)
+	if len(anyOf) != 2 || anyOf[0].(map[string]any)["$ref"] != "../kitchen/food.schema.json#/$defs/key/properties/id" {
+		t.Fatal("expected optional ref across schemas: ", co)
+	}
+}
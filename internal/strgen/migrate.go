@@ -0,0 +1,690 @@
+package strgen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// MigrationStyle is how migration files are named and laid out
+type MigrationStyle int
+
+// recognized migration styles
+const (
+	// MigrationUnix pairs <unix>_<name>.up.sql and .down.sql, as golang-migrate reads
+	MigrationUnix MigrationStyle = iota
+	// MigrationSequence pairs <000001>_<name>.up.sql and .down.sql, as golang-migrate reads
+	MigrationSequence
+	// MigrationGoose is a single <yyyymmddhhmmss>_<name>.sql annotated for goose
+	MigrationGoose
+	// MigrationDbmate is a single <yyyymmddhhmmss>_<name>.sql annotated for dbmate
+	MigrationDbmate
+)
+
+// MigrationOptions tune how migration files are written
+type MigrationOptions struct {
+	Style MigrationStyle
+	// Sequence is the number of a sequential migration, from 1
+	Sequence int
+}
+
+// files names the up and down of a migration by its style
+func (opts MigrationOptions) files(name, up, down string) map[FileName]string {
+	var (
+		m     = make(map[FileName]string, 2)
+		now   = time.Now().UTC()
+		stamp = now.Format("20060102150405")
+	)
+
+	switch opts.Style {
+	case MigrationSequence:
+		prefix := fmt.Sprintf("%06d_%s", max(opts.Sequence, 1), name)
+		m[newFileName("migrations", prefix+".up.sql")] = up
+		m[newFileName("migrations", prefix+".down.sql")] = down
+	case MigrationGoose:
+		s := fmt.Sprintf("-- +goose Up\n%s\n-- +goose Down\n%s", up, down)
+		m[newFileName("migrations", fmt.Sprintf("%s_%s.sql", stamp, name))] = s
+	case MigrationDbmate:
+		s := fmt.Sprintf("-- migrate:up\n%s\n-- migrate:down\n%s", up, down)
+		m[newFileName("migrations", fmt.Sprintf("%s_%s.sql", stamp, name))] = s
+	default:
+		prefix := fmt.Sprintf("%d_%s", now.Unix(), name)
+		m[newFileName("migrations", prefix+".up.sql")] = up
+		m[newFileName("migrations", prefix+".down.sql")] = down
+	}
+	return m
+}
+
+// migratePhase orders the statements of a migration, so each runs
+// after what it depends on regardless of the order changes are found
+type migratePhase int
+
+// phases of a migration, in the order they run
+const (
+	phaseSchema migratePhase = iota
+	phaseRename
+	phaseType
+	phaseDropConstraint
+	phaseTable
+	phaseColumn
+	phaseConstraint
+	phaseDropColumn
+	phaseDropTable
+	phaseDropType
+	phaseDropSchema
+	phaseCount
+)
+
+// matchKey is how an old and a new item are paired. An empty key never matches.
+type matchKey[T any] struct {
+	old, new func(T) string
+}
+
+// sameKey is a match key read the same way from old and new
+func sameKey[T any](fn func(T) string) matchKey[T] {
+	return matchKey[T]{old: fn, new: fn}
+}
+
+// matchBy pairs each old item with a new one, trying each key in
+// turn so a stable id wins over a name
+func matchBy[T comparable](old, new []T, keys ...matchKey[T]) map[T]T {
+	m := make(map[T]T, len(old))
+	used := make(map[T]bool, len(new))
+
+	for _, key := range keys {
+		for _, o := range old {
+			if _, ok := m[o]; ok {
+				continue
+			}
+			k := key.old(o)
+			if len(k) == 0 {
+				continue
+			}
+			for _, n := range new {
+				if used[n] || key.new(n) != k {
+					continue
+				}
+				m[o] = n
+				used[n] = true
+				break
+			}
+		}
+	}
+	return m
+}
+
+// inverse flips a match so the old item can be found from the new one
+func inverse[T comparable](m map[T]T) map[T]T {
+	inv := make(map[T]T, len(m))
+	for k, v := range m {
+		inv[v] = k
+	}
+	return inv
+}
+
+// migrateType is the type a column is altered to. A serial or generated
+// uuid is only a type with a default when a column is created.
+func migrateType(attr *model.Attribute) string {
+	if attr.DirectChild && attr.Final.Kind.Generated() {
+		return _postgresKind[attr.Final.Kind.Base()]
+	}
+	return renderKind(attr)
+}
+
+// migrateDefault is the default of a column, including the one a
+// generated uuid is created with
+func migrateDefault(attr *model.Attribute) string {
+	if attr.DirectChild && attr.Final.Kind == model.AttrKindGeneratedUUID {
+		return "gen_random_uuid()"
+	}
+	return renderDefault(attr)
+}
+
+// uniqueGroups are the unique groups of an entity ordered by label
+func uniqueGroups(ent *model.Entity) [][]*model.Attribute {
+	unique := ent.Unique()
+	labels := make([]string, 0, len(unique))
+	for k := range unique {
+		labels = append(labels, k)
+	}
+	slices.Sort(labels)
+
+	groups := make([][]*model.Attribute, 0, len(labels))
+	for _, label := range labels {
+		groups = append(groups, unique[label])
+	}
+	return groups
+}
+
+func attrNames(attrs []*model.Attribute) []string {
+	names := make([]string, 0, len(attrs))
+	for _, attr := range attrs {
+		names = append(names, attr.Name())
+	}
+	return names
+}
+
+// pgMigrate is the difference of an old and new model as postgres statements
+type pgMigrate struct {
+	tmpl *template.Template
+	// order is that of the new entities, which are created in it
+	order model.EntityOrder
+
+	schemas  map[*model.Schema]*model.Schema
+	entities map[*model.Entity]*model.Entity
+	attrs    map[*model.AttributeRaw]*model.AttributeRaw
+
+	phases [phaseCount][]string
+	// warnings are drops alongside adds, which lose data if meant as renames,
+	// and required columns added to rows that have nothing to fill them
+	warnings []string
+}
+
+func newPGMigrate(tmpl *template.Template, order model.EntityOrder, old, new []*model.Schema) *pgMigrate {
+	m := pgMigrate{tmpl: tmpl, order: order}
+
+	m.schemas = matchBy(old, new,
+		sameKey(func(s *model.Schema) string { return s.ID }),
+		sameKey(func(s *model.Schema) string { return s.Name }),
+	)
+
+	var oldEntities, newEntities []*model.Entity
+	for _, s := range old {
+		oldEntities = append(oldEntities, s.Entities...)
+	}
+	for _, s := range new {
+		newEntities = append(newEntities, s.Entities...)
+	}
+
+	// by name an entity only matches within the schema it was matched to
+	m.entities = matchBy(oldEntities, newEntities,
+		sameKey(func(e *model.Entity) string { return e.ID }),
+		matchKey[*model.Entity]{
+			old: func(e *model.Entity) string {
+				s, ok := m.schemas[e.Parent]
+				if !ok {
+					return ""
+				}
+				return s.Name + "." + e.Name
+			},
+			new: func(e *model.Entity) string {
+				return e.Parent.Name + "." + e.Name
+			},
+		},
+	)
+
+	m.attrs = make(map[*model.AttributeRaw]*model.AttributeRaw)
+	for o, n := range m.entities {
+		matched := matchBy(o.RawAttributes, n.RawAttributes,
+			sameKey(func(a *model.AttributeRaw) string { return a.ID }),
+			sameKey(func(a *model.AttributeRaw) string { return a.Name }),
+		)
+		for k, v := range matched {
+			m.attrs[k] = v
+		}
+	}
+
+	return &m
+}
+
+func (m *pgMigrate) add(p migratePhase, format string, a ...any) {
+	m.phases[p] = append(m.phases[p], fmt.Sprintf(format, a...))
+}
+
+// exec adds the output of a postgres table template
+func (m *pgMigrate) exec(p migratePhase, name string, data any) error {
+	sb := strings.Builder{}
+	err := m.tmpl.ExecuteTemplate(&sb, name, data)
+	if err != nil {
+		return err
+	}
+	s := strings.TrimSpace(sb.String())
+	if len(s) > 0 {
+		m.phases[p] = append(m.phases[p], s)
+	}
+	return nil
+}
+
+// warnLoss notes what is dropped from where something is also added, as
+// a rename is only found when ids match and is otherwise a loss of data
+func (m *pgMigrate) warnLoss(where string, dropped, added []string) {
+	if len(dropped) == 0 || len(added) == 0 {
+		return
+	}
+	m.warnings = append(m.warnings, fmt.Sprintf("%s drops %s and adds %s, losing the data of what is dropped if it was renamed",
+		where, strings.Join(dropped, ", "), strings.Join(added, ", ")))
+}
+
+// addRequired adds a column that may not be null and has no default. Rows
+// that exist have nothing to fill it with, so it is added nullable to be
+// backfilled before it is set not null.
+func (m *pgMigrate) addRequired(table, col, attr string) {
+	m.warnings = append(m.warnings, fmt.Sprintf("%s adds %s as not null without a default, backfill it before it is set not null",
+		table, col))
+	m.add(phaseColumn, "ALTER TABLE %s ADD COLUMN %s;", table, strings.TrimSuffix(attr, " NOT NULL"))
+	m.add(phaseColumn, "-- TODO: UPDATE %s SET %s = ... WHERE %s IS NULL;", table, col, col)
+	m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, col)
+}
+
+func (m *pgMigrate) String() string {
+	phases := make([]string, 0, phaseCount+1)
+	if len(m.warnings) > 0 {
+		warnings := make([]string, 0, len(m.warnings))
+		for _, w := range m.warnings {
+			warnings = append(warnings, "-- WARNING: "+w)
+		}
+		phases = append(phases, strings.Join(warnings, "\n"))
+	}
+	for _, stmts := range m.phases {
+		if len(stmts) == 0 {
+			continue
+		}
+		phases = append(phases, strings.Join(stmts, "\n"))
+	}
+	if len(phases) == 0 {
+		return ""
+	}
+	return strings.Join(phases, "\n\n") + "\n"
+}
+
+// schemaName is where an old schema is after it is renamed
+func (m *pgMigrate) schemaName(sch *model.Schema) string {
+	if n, ok := m.schemas[sch]; ok {
+		return n.Name
+	}
+	return sch.Name
+}
+
+func (m *pgMigrate) diff(old, new []*model.Schema) error {
+	var (
+		oldSchemas  = inverse(m.schemas)
+		oldEntities = inverse(m.entities)
+	)
+
+	for _, o := range old {
+		n, ok := m.schemas[o]
+		if !ok {
+			m.add(phaseDropSchema, "DROP SCHEMA %s;", o.Name)
+			continue
+		}
+		if o.Name != n.Name {
+			m.add(phaseSchema, "ALTER SCHEMA %s RENAME TO %s;", o.Name, n.Name)
+		}
+	}
+	for _, n := range new {
+		if _, ok := oldSchemas[n]; !ok {
+			m.add(phaseSchema, "CREATE SCHEMA %s;", n.Name)
+		}
+	}
+
+	// tables dropped and added are by the schema they are in after
+	var (
+		droppedTables = make(map[string][]string)
+		addedTables   = make(map[string][]string)
+	)
+
+	for _, n := range m.order.Entities {
+		if _, ok := oldEntities[n]; ok {
+			continue
+		}
+		addedTables[n.Parent.Name] = append(addedTables[n.Parent.Name], n.Name)
+		if err := m.exec(phaseType, "enums", n); err != nil {
+			return err
+		}
+		if err := m.exec(phaseTable, "entity", n); err != nil {
+			return err
+		}
+	}
+	for _, attr := range m.order.Deferred {
+		if _, ok := oldEntities[attr.Source.Parent]; ok {
+			continue
+		}
+		m.add(phaseConstraint, "ALTER TABLE %s.%s ADD FOREIGN KEY(%s) REFERENCES %s%s;",
+			attr.Source.Parent.Parent.Name, attr.Source.Parent.Name, attr.Name(), renderQualifiedReference(attr), renderActions(attr))
+	}
+
+	for _, s := range old {
+		for _, o := range s.Entities {
+			n, ok := m.entities[o]
+			if !ok {
+				continue
+			}
+			if err := m.alterEntity(o, n); err != nil {
+				return err
+			}
+		}
+	}
+
+	// dropped in reverse of the order they are created in, so a table goes
+	// before the ones it references. A reference closing a cycle is
+	// dropped first, as neither table of it can go before the other.
+	oldOrder := model.OrderEntities(old)
+	for _, attr := range oldOrder.Deferred {
+		o := attr.Source.Parent
+		if _, ok := m.entities[o]; ok {
+			continue
+		}
+		m.add(phaseDropConstraint, "ALTER TABLE %s.%s DROP CONSTRAINT %s;", m.schemaName(o.Parent), o.Name, renderForeignKeyName(attr))
+	}
+	for _, o := range oldOrder.Reversed() {
+		if _, ok := m.entities[o]; ok {
+			continue
+		}
+		sch := m.schemaName(o.Parent)
+		droppedTables[sch] = append(droppedTables[sch], o.Name)
+		m.add(phaseDropTable, "DROP TABLE %s.%s;", sch, o.Name)
+		for _, attr := range o.RawAttributes {
+			if attr.Kind == model.AttrKindEnum && !attr.HasErr() {
+				m.add(phaseDropType, "DROP TYPE %s;", enumTypeName(sch, o.Name, attr.Name))
+			}
+		}
+	}
+	for _, n := range new {
+		m.warnLoss(n.Name, droppedTables[n.Name], addedTables[n.Name])
+	}
+
+	return nil
+}
+
+func (m *pgMigrate) alterEntity(o, n *model.Entity) error {
+	sch := m.schemaName(o.Parent)
+	if sch != n.Parent.Name {
+		m.add(phaseRename, "ALTER TABLE %s.%s SET SCHEMA %s;", sch, o.Name, n.Parent.Name)
+	}
+	if o.Name != n.Name {
+		m.add(phaseRename, "ALTER TABLE %s.%s RENAME TO %s;", n.Parent.Name, o.Name, n.Name)
+	}
+	table := fmt.Sprintf("%s.%s", n.Parent.Name, n.Name)
+
+	// an enum type is recreated if its values are not only appended to,
+	// and the column it is on must be cast to the new one
+	recreated := make(map[*model.AttributeRaw]bool)
+	oldAttrs := inverse(m.attrs)
+
+	for _, oa := range o.RawAttributes {
+		if oa.Kind != model.AttrKindEnum || oa.HasErr() {
+			continue
+		}
+		current := enumTypeName(sch, o.Name, oa.Name)
+
+		na, ok := m.attrs[oa]
+		if !ok || na.Kind != model.AttrKindEnum || na.HasErr() {
+			m.add(phaseDropType, "DROP TYPE %s;", current)
+			continue
+		}
+		if m.alterEnum(table, current, oa, na) {
+			recreated[na] = true
+		}
+	}
+	for _, na := range n.RawAttributes {
+		if na.Kind != model.AttrKindEnum || na.HasErr() {
+			continue
+		}
+		if oa, ok := oldAttrs[na]; ok && oa.Kind == model.AttrKindEnum && !oa.HasErr() {
+			continue
+		}
+		m.add(phaseType, "CREATE TYPE %s AS ENUM (%s);", renderEnumType(na), renderEnumValues(na))
+	}
+
+	// a column is the same if it comes from, and ends at, the same attributes
+	pairs := make(map[*model.Attribute]*model.Attribute)
+	kept := make(map[*model.Attribute]bool)
+	for _, oa := range o.Attributes() {
+		for _, na := range n.Attributes() {
+			if kept[na] || m.attrs[oa.Source] != na.Source || m.attrs[oa.Final] != na.Final {
+				continue
+			}
+			pairs[oa] = na
+			kept[na] = true
+			break
+		}
+	}
+
+	var dropped, added []string
+
+	for _, oa := range o.Attributes() {
+		na, ok := pairs[oa]
+		if !ok {
+			dropped = append(dropped, oa.Name())
+			m.add(phaseDropColumn, "ALTER TABLE %s DROP COLUMN %s;", table, oa.Name())
+			continue
+		}
+		if oa.Name() != na.Name() {
+			m.add(phaseRename, "ALTER TABLE %s RENAME COLUMN %s TO %s;", table, oa.Name(), na.Name())
+		}
+		m.alterColumn(table, oa, na, recreated[na.Final])
+	}
+
+	for _, na := range n.Attributes() {
+		if kept[na] {
+			continue
+		}
+		sb := strings.Builder{}
+		err := m.tmpl.ExecuteTemplate(&sb, "attribute", na)
+		if err != nil {
+			return err
+		}
+		added = append(added, na.Name())
+		if !renderIsNotNull(na) || len(migrateDefault(na)) > 0 {
+			m.add(phaseColumn, "ALTER TABLE %s ADD COLUMN %s;", table, sb.String())
+		} else {
+			m.addRequired(table, na.Name(), sb.String())
+		}
+		if !na.DirectChild {
+			m.add(phaseConstraint, "ALTER TABLE %s ADD FOREIGN KEY(%s) REFERENCES %s%s;", table, na.Name(), renderQualifiedReference(na), renderActions(na))
+		}
+	}
+
+	m.warnLoss(table, dropped, added)
+
+	// old keys are compared by the names their columns have now
+	translate := func(attrs []*model.Attribute) string {
+		names := make([]string, 0, len(attrs))
+		for _, attr := range attrs {
+			na, ok := pairs[attr]
+			if !ok {
+				return ""
+			}
+			names = append(names, na.Name())
+		}
+		return strings.Join(names, ", ")
+	}
+
+	oldPrimary, newPrimary := o.Primary(), n.Primary()
+	if translate(oldPrimary) != strings.Join(attrNames(newPrimary), ", ") {
+		if len(oldPrimary) > 0 {
+			m.add(phaseDropConstraint, "ALTER TABLE %s DROP CONSTRAINT %s;", table, pgConstraintName(o.Name, nil, "pkey"))
+		}
+		if len(newPrimary) > 0 {
+			m.add(phaseConstraint, "ALTER TABLE %s ADD PRIMARY KEY(%s);", table, strings.Join(attrNames(newPrimary), ", "))
+		}
+	}
+
+	var (
+		oldUnique = uniqueGroups(o)
+		newUnique = uniqueGroups(n)
+		oldKeys   = make([]string, 0, len(oldUnique))
+		newKeys   = make([]string, 0, len(newUnique))
+	)
+	for _, group := range oldUnique {
+		oldKeys = append(oldKeys, translate(group))
+	}
+	for _, group := range newUnique {
+		newKeys = append(newKeys, strings.Join(attrNames(group), ", "))
+	}
+	for i, group := range oldUnique {
+		if !slices.Contains(newKeys, oldKeys[i]) {
+			m.add(phaseDropConstraint, "ALTER TABLE %s DROP CONSTRAINT %s;", table, pgConstraintName(o.Name, attrNames(group), "key"))
+		}
+	}
+	for _, key := range newKeys {
+		if !slices.Contains(oldKeys, key) {
+			m.add(phaseConstraint, "ALTER TABLE %s ADD UNIQUE(%s);", table, key)
+		}
+	}
+
+	return nil
+}
+
+// alterEnum moves and renames an enum type to where the new attribute
+// has it, then changes its values. It is true if the type is recreated.
+func (m *pgMigrate) alterEnum(table, current string, oa, na *model.AttributeRaw) bool {
+	sch := na.Parent.Parent.Name
+	if s, name, _ := strings.Cut(current, "."); s != sch {
+		m.add(phaseRename, "ALTER TYPE %s SET SCHEMA %s;", current, sch)
+		current = fmt.Sprintf("%s.%s", sch, name)
+	}
+
+	t := renderEnumType(na)
+	_, name, _ := strings.Cut(t, ".")
+	if current != t {
+		m.add(phaseRename, "ALTER TYPE %s RENAME TO %s;", current, name)
+	}
+
+	if slices.Equal(oa.EnumValues, na.EnumValues) {
+		return false
+	}
+
+	// values can be appended to a type, but not removed or reordered
+	if len(na.EnumValues) > len(oa.EnumValues) && slices.Equal(oa.EnumValues, na.EnumValues[:len(oa.EnumValues)]) {
+		for _, v := range na.EnumValues[len(oa.EnumValues):] {
+			m.add(phaseType, "ALTER TYPE %s ADD VALUE '%s';", t, strings.ReplaceAll(v, "'", "''"))
+		}
+		return false
+	}
+
+	m.add(phaseType, "ALTER TYPE %s RENAME TO %s_old;", t, name)
+	m.add(phaseType, "CREATE TYPE %s AS ENUM (%s);", t, renderEnumValues(na))
+	// a default of the old type cannot be cast with the column
+	if len(oa.DefaultValue) > 0 {
+		m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, na.Name)
+	}
+	m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::text::%s;", table, na.Name, t, na.Name, t)
+	m.add(phaseDropType, "DROP TYPE %s_old;", t)
+	return true
+}
+
+// alterColumn changes the type, default and null constraint of a column.
+// A recreated enum had its default dropped, so it is set again.
+func (m *pgMigrate) alterColumn(table string, oa, na *model.Attribute, recreated bool) {
+	col := na.Name()
+
+	oldSerial := oa.DirectChild && oa.Final.Kind == model.AttrKindSerial
+	newSerial := na.DirectChild && na.Final.Kind == model.AttrKindSerial
+	if newSerial && !oldSerial {
+		m.add(phaseColumn, "-- %s.%s cannot become serial, recreate the column", table, col)
+		return
+	}
+
+	oldDefault, newDefault := migrateDefault(oa), migrateDefault(na)
+	if oldSerial && !newSerial {
+		m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, col)
+		oldDefault = ""
+	}
+
+	// an enum keeps its type as it is renamed, and changes its values in place
+	oldEnum := oa.Final.Kind == model.AttrKindEnum
+	newEnum := na.Final.Kind == model.AttrKindEnum
+	if !oldEnum || !newEnum {
+		t := migrateType(na)
+		if migrateType(oa) != t {
+			using := fmt.Sprintf("%s::%s", col, t)
+			if oldEnum || newEnum {
+				using = fmt.Sprintf("%s::text::%s", col, t)
+			}
+			m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s;", table, col, t, using)
+		}
+	}
+
+	switch {
+	case recreated:
+		if len(newDefault) > 0 {
+			m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, col, newDefault)
+		}
+	case oldDefault != newDefault:
+		if len(newDefault) > 0 {
+			m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", table, col, newDefault)
+		} else {
+			m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", table, col)
+		}
+	}
+
+	if renderIsNotNull(oa) != renderIsNotNull(na) {
+		if renderIsNotNull(na) {
+			m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", table, col)
+		} else {
+			m.add(phaseColumn, "ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", table, col)
+		}
+	}
+
+	// an action cannot be altered, the foreign key is replaced
+	if !na.DirectChild && renderActions(oa) != renderActions(na) {
+		m.add(phaseDropConstraint, "ALTER TABLE %s DROP CONSTRAINT %s;", table, renderForeignKeyName(oa))
+		m.add(phaseConstraint, "ALTER TABLE %s ADD FOREIGN KEY(%s) REFERENCES %s%s;", table, col, renderQualifiedReference(na), renderActions(na))
+	}
+}
+
+// pgConstraintName is the name postgres gives a constraint that is
+// not named, which is how PostgresSetup creates them
+func pgConstraintName(table string, columns []string, suffix string) string {
+	parts := append([]string{table}, columns...)
+	parts = append(parts, suffix)
+	return strings.Join(parts, "_")
+}
+
+func diffPostgres(old, new []*model.Schema) (*pgMigrate, error) {
+	order := model.OrderEntities(new)
+
+	tmpl, err := parseTemplates(postgresFuncs(order), "postgres/tables/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	m := newPGMigrate(tmpl, order, old, new)
+	err = m.diff(old, new)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PostgresMigrate generates the up and down statements to migrate a database
+// from an old model to a new one. Schemas, entities and attributes are matched
+// by id, then by name, so a rename is only found when the ids match. The
+// ui keeps ids as a schema is edited, but the parser gives new ones each
+// parse, so between parsed files a rename is a drop and an add.
+// Constraints are expected to have the names postgres gives them. A
+// drop alongside an add is warned of atop the script, see PostgresMigrateWarnings.
+func PostgresMigrate(old, new []*model.Schema, opts MigrationOptions) (map[FileName]string, error) {
+	upMigrate, err := diffPostgres(old, new)
+	if err != nil {
+		return nil, err
+	}
+	downMigrate, err := diffPostgres(new, old)
+	if err != nil {
+		return nil, err
+	}
+	up, down := upMigrate.String(), downMigrate.String()
+
+	if len(up) == 0 && len(down) == 0 {
+		return make(map[FileName]string), nil
+	}
+	return opts.files("migrate", up, down), nil
+}
+
+// PostgresMigrateWarnings are the changes migrating from an old model to a new
+// one makes that lose data: a column or table dropped where another is added.
+// As ids differ between parses, such a change may be a rename that was not found.
+// A required column added without a default is also noted, as it must be
+// backfilled before it is set not null.
+func PostgresMigrateWarnings(old, new []*model.Schema) ([]string, error) {
+	m, err := diffPostgres(old, new)
+	if err != nil {
+		return nil, err
+	}
+	return m.warnings, nil
+}
@@ -0,0 +1,309 @@
+package strgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Isaac799/devtoolbox/internal/strparse"
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// keepIDs gives new the ids of old by position, as the ui
+// keeps them while a schema is edited
+func keepIDs(old, new []*model.Schema) {
+	for i, sch := range old {
+		new[i].ID = sch.ID
+		for j, ent := range sch.Entities {
+			new[i].Entities[j].ID = ent.ID
+			for k, attr := range ent.RawAttributes {
+				new[i].Entities[j].RawAttributes[k].ID = attr.ID
+			}
+		}
+	}
+}
+
+func migrations(t *testing.T, m map[FileName]string) (string, string) {
+	var up, down string
+	for k, v := range m {
+		if k.Path() != "migrations" {
+			t.Fatal("expected in migrations: ", k)
+		}
+		switch {
+		case strings.HasSuffix(k.Full(), ".up.sql"):
+			up = v
+		case strings.HasSuffix(k.Full(), ".down.sql"):
+			down = v
+		default:
+			t.Fatal("expected up or down: ", k)
+		}
+	}
+	return up, down
+}
+
+func expectInOrder(t *testing.T, s string, stmts ...string) {
+	t.Helper()
+	at := 0
+	for _, stmt := range stmts {
+		i := strings.Index(s[at:], stmt)
+		if i < 0 {
+			t.Fatalf("expected %q in order in:\n%s", stmt, s)
+		}
+		at += i + len(stmt)
+	}
+}
+
+func TestPostgresMigrate(t *testing.T) {
+	old := strparse.Raw(testMockSchemaKitchen)
+	new := strparse.Raw(`# Kitchen
+
+## Supplier
+- k1 as ++
+- k2 as ++
+- name        as str  with required, 3..60
+- phone       as str  with ..20
+
+## Ingredient
+- id          as bit with primary, ..16
+- name        as str  with 3..30, unique:name, d: bar
+- eol         as date with required, 2006-01-02..2007-03-04
+- flags       as bit with  ..8
+- storage     as enum(dry, cold, frozen, wet) with required
+- @supplier   with r
+
+## Food
+- id          as ++
+- name        as text with required
+- @supplier
+
+## Recipe
+- @food               with required, primary
+- @ingredient         with required, primary
+- amount      as int  with required
+` + "\n" + testMockSchemaShop)
+
+	m, err := PostgresMigrate(old, new, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, down := migrations(t, m)
+
+	expectInOrder(t, up,
+		"CREATE SCHEMA shop;",
+		"ALTER TYPE kitchen.ingredient_storage ADD VALUE 'wet';",
+		"ALTER TABLE kitchen.food DROP CONSTRAINT food_name_key;",
+		"CREATE TABLE shop.tenant (",
+		"ALTER TABLE kitchen.supplier ALTER COLUMN name TYPE VARCHAR(60) USING name::VARCHAR(60);",
+		"ALTER TABLE kitchen.supplier ADD COLUMN phone VARCHAR(20);",
+		"ALTER TABLE kitchen.ingredient ALTER COLUMN name SET DEFAULT 'bar';",
+		"ALTER TABLE kitchen.ingredient ALTER COLUMN name DROP NOT NULL;",
+		"ALTER TABLE kitchen.food ALTER COLUMN name TYPE TEXT USING name::TEXT;",
+		"ALTER TABLE kitchen.food ADD COLUMN supplier_k_1 INT;",
+		"ALTER TABLE kitchen.food ADD FOREIGN KEY(supplier_k_1) REFERENCES kitchen.supplier(k_1);",
+		"ALTER TABLE kitchen.ingredient DROP COLUMN rare;",
+	)
+	if strings.Contains(up, "DROP TABLE") || strings.Contains(up, "CREATE SCHEMA kitchen") {
+		t.Fatal("expected existing tables to be altered: ", up)
+	}
+
+	// values cannot be removed from a type, so it is recreated
+	expectInOrder(t, down,
+		"ALTER TYPE kitchen.ingredient_storage RENAME TO ingredient_storage_old;",
+		"CREATE TYPE kitchen.ingredient_storage AS ENUM ('dry', 'cold', 'frozen');",
+		"ALTER TABLE kitchen.ingredient ALTER COLUMN storage TYPE kitchen.ingredient_storage USING storage::text::kitchen.ingredient_storage;",
+		"ALTER TABLE kitchen.food ADD UNIQUE(name);",
+		"ALTER TABLE kitchen.food DROP COLUMN supplier_k_1;",
+		"DROP TABLE shop.item;",
+		"DROP TABLE shop.tenant;",
+		"DROP TYPE kitchen.ingredient_storage_old;",
+		"DROP SCHEMA shop;",
+	)
+}
+
+func TestPostgresMigrateRename(t *testing.T) {
+	old := strparse.Raw(testMockSchemaKitchen)
+	new := strparse.Raw(strings.NewReplacer(
+		"# Kitchen", "# Cookery",
+		"## Food", "## Dish",
+		"@food", "@dish",
+		"- amount", "- grams",
+		"- storage     as enum(dry, cold, frozen)", "- keep        as enum(dry, cold, frozen)",
+	).Replace(testMockSchemaKitchen))
+	keepIDs(old, new)
+
+	m, err := PostgresMigrate(old, new, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, down := migrations(t, m)
+
+	expectInOrder(t, up,
+		"ALTER SCHEMA kitchen RENAME TO cookery;",
+		"ALTER TYPE cookery.ingredient_storage RENAME TO ingredient_keep;",
+		"ALTER TABLE cookery.ingredient RENAME COLUMN storage TO keep;",
+		"ALTER TABLE cookery.food RENAME TO dish;",
+		"ALTER TABLE cookery.recipe RENAME COLUMN food_id TO dish_id;",
+		"ALTER TABLE cookery.recipe RENAME COLUMN amount TO grams;",
+	)
+	if strings.Contains(up, "DROP") || strings.Contains(up, "ADD") || strings.Contains(up, "CREATE") {
+		t.Fatal("expected only renames: ", up)
+	}
+	expectInOrder(t, down,
+		"ALTER SCHEMA cookery RENAME TO kitchen;",
+		"ALTER TABLE kitchen.dish RENAME TO food;",
+	)
+}
+
+func TestPostgresMigrateRenameWarning(t *testing.T) {
+	// parsed apart, as from the command line, nothing keeps the ids
+	old := strparse.Raw(testMockSchemaKitchen)
+	new := strparse.Raw(strings.NewReplacer(
+		"- amount", "- grams",
+	).Replace(testMockSchemaKitchen))
+
+	m, err := PostgresMigrate(old, new, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, down := migrations(t, m)
+	expectInOrder(t, up,
+		"-- WARNING: kitchen.recipe drops amount and adds grams",
+		"ALTER TABLE kitchen.recipe ADD COLUMN grams",
+		"ALTER TABLE kitchen.recipe DROP COLUMN amount;",
+	)
+	expectInOrder(t, down, "-- WARNING: kitchen.recipe drops grams and adds amount")
+
+	warnings, err := PostgresMigrateWarnings(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasWarning(warnings, "kitchen.recipe drops amount and adds grams") {
+		t.Fatal("expected a warning of the column: ", warnings)
+	}
+
+	new = strparse.Raw(strings.NewReplacer("## Food", "## Dish", "@food", "@dish").Replace(testMockSchemaKitchen))
+	if warnings, _ := PostgresMigrateWarnings(old, new); !hasWarning(warnings, "kitchen drops food and adds dish") {
+		t.Fatal("expected a warning of the table: ", warnings)
+	}
+}
+
+// hasWarning is if any warning starts with the prefix
+func hasWarning(warnings []string, prefix string) bool {
+	for _, w := range warnings {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPostgresMigrateAddRequired(t *testing.T) {
+	old := strparse.Raw(testMockSchemaKitchen)
+	new := strparse.Raw(testMockSchemaKitchen + `
+- cost        as int  with required
+- note        as str  with required, 3..30, d: none`)
+
+	m, err := PostgresMigrate(old, new, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, _ := migrations(t, m)
+
+	// rows that exist have no cost, so it is filled before it is required
+	expectInOrder(t, up,
+		"-- WARNING: kitchen.recipe adds cost as not null without a default",
+		"ALTER TABLE kitchen.recipe ADD COLUMN cost INT;",
+		"-- TODO: UPDATE kitchen.recipe SET cost = ... WHERE cost IS NULL;",
+		"ALTER TABLE kitchen.recipe ALTER COLUMN cost SET NOT NULL;",
+	)
+	if !strings.Contains(up, "ALTER TABLE kitchen.recipe ADD COLUMN note VARCHAR") || strings.Contains(up, "note IS NULL") {
+		t.Fatal("expected a default to fill what exists: ", up)
+	}
+
+	warnings, err := PostgresMigrateWarnings(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 || !hasWarning(warnings, "kitchen.recipe adds cost") {
+		t.Fatal("expected a warning of the required column: ", warnings)
+	}
+}
+
+func TestPostgresMigrateAction(t *testing.T) {
+	old := strparse.Raw(testMockSchemaKitchen)
+	new := strparse.Raw(strings.Replace(testMockSchemaKitchen,
+		"- @food               with required, primary",
+		"- @food               with required, primary, cascade", 1))
+	keepIDs(old, new)
+
+	m, err := PostgresMigrate(old, new, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	up, down := migrations(t, m)
+
+	expectInOrder(t, up,
+		"ALTER TABLE kitchen.recipe DROP CONSTRAINT recipe_food_id_fkey;",
+		"ALTER TABLE kitchen.recipe ADD FOREIGN KEY(food_id) REFERENCES kitchen.food(id) ON DELETE CASCADE;",
+	)
+	expectInOrder(t, down,
+		"ALTER TABLE kitchen.recipe DROP CONSTRAINT recipe_food_id_fkey;",
+		"ALTER TABLE kitchen.recipe ADD FOREIGN KEY(food_id) REFERENCES kitchen.food(id);",
+	)
+}
+
+func TestPostgresMigrateUnchanged(t *testing.T) {
+	m, err := PostgresMigrate(strparse.Raw(testMockSchemas), strparse.Raw(testMockSchemas), MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) > 0 {
+		t.Fatal("expected no migration: ", m)
+	}
+}
+
+func TestPostgresMigrateDropReferenced(t *testing.T) {
+	old := strparse.Raw(`# Shop
+
+## Order
+- id as ++
+- @customer with required
+
+## Customer
+- id as ++
+
+## Keep
+- id as ++
+
+## Left
+- id as ++
+- @right
+
+## Right
+- id as ++
+- @left`)
+	new := strparse.Raw(`# Shop
+
+## Keep
+- id as ++`)
+
+	m, err := PostgresMigrate(old, new, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, down := migrations(t, m)
+	expectInOrder(t, up,
+		"ALTER TABLE shop.right DROP CONSTRAINT right_left_id_fkey;",
+		"DROP TABLE shop.order;",
+		"DROP TABLE shop.customer;",
+	)
+	expectInOrder(t, up, "DROP TABLE shop.left;", "DROP TABLE shop.right;")
+	if strings.Contains(up, "shop.keep") {
+		t.Fatal("expected a table kept to be left alone: ", up)
+	}
+	expectInOrder(t, down,
+		"CREATE TABLE shop.customer",
+		"CREATE TABLE shop.order",
+		"ALTER TABLE shop.right ADD FOREIGN KEY(left_id) REFERENCES shop.left(id);",
+	)
+}
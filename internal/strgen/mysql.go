@@ -0,0 +1,135 @@
+package strgen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+var _mySQLKind = map[model.AttrKind]string{
+	model.AttrKindNone:      "???",
+	model.AttrKindReference: "REF",
+	model.AttrKindSerial:    "INT AUTO_INCREMENT",
+	model.AttrKindInt:       "INT",
+	model.AttrKindChar:      "CHAR",
+	model.AttrKindString:    "VARCHAR",
+	model.AttrKindBit:       "BIT",
+	model.AttrKindBoolean:   "BOOLEAN",
+	model.AttrKindDate:      "DATE",
+	model.AttrKindTime:      "TIME",
+	model.AttrKindTimestamp: "DATETIME",
+	model.AttrKindFloat:     "DOUBLE",
+	model.AttrKindReal:      "FLOAT",
+	model.AttrKindDecimal:   "DECIMAL(38, 10)",
+	model.AttrKindMoney:     "DECIMAL(19, 4)",
+	model.AttrKindEnum:      "ENUM",
+
+	model.AttrKindUUID:          "CHAR(36)",
+	model.AttrKindGeneratedUUID: "CHAR(36) DEFAULT (UUID())",
+	model.AttrKindText:          "TEXT",
+	model.AttrKindJSON:          "JSON",
+	model.AttrKindBytes:         "BLOB",
+}
+
+func renderMySQLKind(attr *model.Attribute) string {
+	// there are no array columns, a list is held as a document
+	if attr.Final.Array {
+		return _mySQLKind[model.AttrKindJSON]
+	}
+
+	k := attr.Final.Kind
+
+	if !attr.DirectChild {
+		k = k.Base()
+	}
+
+	s := _mySQLKind[k]
+
+	switch k {
+	case model.AttrKindString, model.AttrKindChar, model.AttrKindBit:
+		i, _ := strconv.Atoi(attr.Final.Max.String)
+		if i > 0 {
+			s = fmt.Sprintf("%s(%d)", s, i)
+		}
+	case model.AttrKindEnum:
+		// an enum is declared on its column rather than as a type
+		s = fmt.Sprintf("%s(%s)", s, renderEnumValues(attr.Final))
+	}
+	return s
+}
+
+// renderMySQLReference is always qualified by database,
+// since the tables are created without one in use
+func renderMySQLReference(attr *model.Attribute) string {
+	if attr.DirectChild {
+		// unreachable
+		return "???"
+	}
+	to := attr.Referenced()
+	return fmt.Sprintf("%s.%s(%s)", to.Parent.Name, to.Name, attr.ReferencedName())
+}
+
+// renderMySQLDefault is the default of a column. A text, document or blob
+// may only have a default as an expression, so it is wrapped in parenthesis.
+func renderMySQLDefault(attr *model.Attribute) string {
+	s := attr.Attribute.DefaultValue
+	if len(s) == 0 {
+		return ""
+	}
+
+	quoted := fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", "''"))
+
+	switch attr.Attribute.Kind {
+	case model.AttrKindString, model.AttrKindEnum, model.AttrKindChar:
+		return quoted
+	case model.AttrKindText, model.AttrKindJSON:
+		return fmt.Sprintf("(%s)", quoted)
+	case model.AttrKindBytes:
+		return fmt.Sprintf("(x'%s')", s)
+	case model.AttrKindBit:
+		return fmt.Sprintf("b'%s'", s)
+	case model.AttrKindDate:
+		if s == "now" {
+			return "(CURRENT_DATE)"
+		}
+		return quoted
+	case model.AttrKindTime:
+		if s == "now" {
+			return "(CURRENT_TIME)"
+		}
+		return quoted
+	case model.AttrKindTimestamp:
+		if s == "now" {
+			return "CURRENT_TIMESTAMP"
+		}
+		return quoted
+	case model.AttrKindUUID:
+		if s == "random" {
+			return "(UUID())"
+		}
+		return quoted
+	default:
+		return s
+	}
+}
+
+// MySQLSetup generates mysql create statements to setup a new database,
+// with each schema as its own database. Tables are created in the order
+// of what they reference, with a reference closing a cycle added after.
+func MySQLSetup(schemas []*model.Schema) (map[FileName]string, error) {
+	return sqlSetup(schemas, sqlDialect{
+		name: "mysql",
+		schema: func(sch *model.Schema) string {
+			return fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s;", sch.Name)
+		},
+		table: func(ent *model.Entity) string {
+			return fmt.Sprintf("%s.%s", ent.Parent.Name, ent.Name)
+		},
+		kind:         renderMySQLKind,
+		defaultValue: renderMySQLDefault,
+		reference:    renderMySQLReference,
+		deferCycles:  true,
+	})
+}
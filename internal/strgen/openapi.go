@@ -0,0 +1,101 @@
+package strgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// renderOpenAPISchema is the schema of an attribute in a record, with
+// a time as go encodes it
+func renderOpenAPISchema(attr *model.Attribute) string {
+	s := jsonSchemaColumn(attr, nil)
+	if jsonSchemaReadOnly(attr) {
+		s = append(s, jsonKeyword{"readOnly", true})
+	}
+	return s.String()
+}
+
+// renderOpenAPIParam is the schema of an attribute given as a path or query value
+func renderOpenAPIParam(attr *model.Attribute) string {
+	return jsonSchemaValue(attr, true).String()
+}
+
+// renderOpenAPIRequired are the attributes of an entity always in a record
+func renderOpenAPIRequired(ent *model.Entity) string {
+	b, _ := json.Marshal(jsonSchemaRequired(ent.Attributes()))
+	return string(b)
+}
+
+// renderOpenAPIName is the name of the component schema of an entity
+func renderOpenAPIName(ent *model.Entity) string {
+	return renderPascalUA(fmt.Sprintf("%s_%s", ent.Parent.Name, ent.Name))
+}
+
+// renderOpenAPIRelationName is the name of the component schema of an
+// entity read with a relation included
+func renderOpenAPIRelationName(rel model.Relation) string {
+	return renderPascalUA(fmt.Sprintf("%s_%s", rel.Base.Parent.Name, rel.Name()))
+}
+
+// openAPISetup is what the spec is written from. Routes are the entities
+// served, each under a path no other entity is under.
+type openAPISetup struct {
+	Schemas []*model.Schema
+	Routes  []*model.Entity
+	// Shadowed are entities with a path that another entity already has
+	Shadowed []*model.Entity
+}
+
+// OpenAPI generates an openapi spec of the rest api the go handlers serve
+func OpenAPI(schemas []*model.Schema) (map[FileName]string, error) {
+	tmpl, err := parseTemplates(template.FuncMap{
+		"renderKebab":               renderKebab,
+		"renderPascal":              renderPascalUA,
+		"renderPathValues":          renderPathValues,
+		"renderOpenAPISchema":       renderOpenAPISchema,
+		"renderOpenAPIParam":        renderOpenAPIParam,
+		"renderOpenAPIRequired":     renderOpenAPIRequired,
+		"renderFilterable":          renderFilterable,
+		"renderIncludes":            renderIncludes,
+		"renderOpenAPIName":         renderOpenAPIName,
+		"renderOpenAPIRelationName": renderOpenAPIRelationName,
+	}, "openapi/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	setup := openAPISetup{
+		Schemas:  schemas,
+		Routes:   make([]*model.Entity, 0, 10),
+		Shadowed: make([]*model.Entity, 0),
+	}
+	routes := make(map[string]bool)
+	for _, s := range schemas {
+		for _, ent := range s.Entities {
+			if !ent.HasPrimary() {
+				continue
+			}
+			route := renderKebab(ent.Name)
+			if routes[route] {
+				setup.Shadowed = append(setup.Shadowed, ent)
+				continue
+			}
+			routes[route] = true
+			setup.Routes = append(setup.Routes, ent)
+		}
+	}
+
+	sb := strings.Builder{}
+	err = tmpl.ExecuteTemplate(&sb, "root.tmpl", setup)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[FileName]string{
+		newFileName("api", "openapi.yaml"): sb.String(),
+	}, nil
+}
@@ -0,0 +1,79 @@
+package strgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Isaac799/devtoolbox/internal/strparse"
+)
+
+func TestOpenAPI(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemas)
+
+	m, err := OpenAPI(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testWriteFiles(t, m)
+}
+
+func TestOpenAPIPaths(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen)
+
+	m, err := OpenAPI(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := m[newFileName("api", "openapi.yaml")]
+	for _, s := range []string{
+		"  /supplier/k-1/{k_1}/k-2/{k_2}:",
+		"  /recipe/food/{food_id}/ingredient/{ingredient_id}:",
+		"        - name: op__amount",
+		`            enum: ["ingredients"]`,
+		`                  - $ref: "#/components/schemas/KitchenSupplierIngredients"`,
+		`            ingredients: {type: "array", items: {$ref: "#/components/schemas/KitchenIngredient"}}`,
+	} {
+		if !strings.Contains(v, s) {
+			t.Fatal("expected in openapi.yaml: ", s)
+		}
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen + "\n" + testMockSchemaShop)
+
+	m, err := OpenAPI(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := m[newFileName("api", "openapi.yaml")]
+	for _, s := range []string{
+		`      required: ["id","name","eol","storage","supplier_k_1","supplier_k_2"]`,
+		`        k_1: {type: "integer", readOnly: true}`,
+		`        name: {type: "string", minLength: 3, maxLength: 30}`,
+		`        storage: {type: "string", enum: ["dry","cold","frozen"]}`,
+		`        rare: {type: ["boolean","null"]}`,
+		`        token: {type: ["string","null"], format: "uuid"}`,
+		`        settings: {}`,
+		`        sizes: {type: ["array","null"], items: {type: "integer", minimum: 1, maximum: 12}}`,
+	} {
+		if !strings.Contains(v, s) {
+			t.Fatal("expected in openapi.yaml: ", s)
+		}
+	}
+	// a document is not filtered on
+	if strings.Contains(v, "name: op__settings") {
+		t.Fatal("expected no filter of a document")
+	}
+
+	m, err = OpenAPI(strparse.Raw(testMockSchemaTree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := `            parent: {oneOf: [{$ref: "#/components/schemas/TreeCategory"}, {type: "null"}]}`; !strings.Contains(m[newFileName("api", "openapi.yaml")], s) {
+		t.Fatal("expected an optional relation to be null without one: ", s)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
-	"time"
 
 	"github.com/Isaac799/devtoolbox/pkg/model"
 )
@@ -26,6 +25,13 @@ var _postgresKind = map[model.AttrKind]string{
 	model.AttrKindReal:      "REAL",
 	model.AttrKindDecimal:   "DECIMAL",
 	model.AttrKindMoney:     "MONEY",
+	model.AttrKindEnum:      "ENUM",
+
+	model.AttrKindUUID:          "UUID",
+	model.AttrKindGeneratedUUID: "UUID DEFAULT gen_random_uuid()",
+	model.AttrKindText:          "TEXT",
+	model.AttrKindJSON:          "JSONB",
+	model.AttrKindBytes:         "BYTEA",
 }
 
 func renderErrs(attr *model.Attribute) string {
@@ -33,7 +39,7 @@ func renderErrs(attr *model.Attribute) string {
 }
 
 func renderIsNotNull(attr *model.Attribute) bool {
-	if attr.Source.Kind == model.AttrKindSerial {
+	if attr.Source.Kind.Generated() {
 		return false
 	}
 	return attr.Source.Required.Valid && attr.Source.Required.Bool
@@ -42,8 +48,8 @@ func renderIsNotNull(attr *model.Attribute) bool {
 func renderKind(attr *model.Attribute) string {
 	k := attr.Final.Kind
 
-	if k == model.AttrKindSerial && !attr.DirectChild {
-		k = model.AttrKindInt
+	if !attr.DirectChild {
+		k = k.Base()
 	}
 
 	s := _postgresKind[k]
@@ -54,20 +60,74 @@ func renderKind(attr *model.Attribute) string {
 		if i > 0 {
 			s = fmt.Sprintf("%s(%d)", s, i)
 		}
+	case model.AttrKindEnum:
+		s = renderEnumType(attr.Final)
+	}
+
+	if attr.Final.Array {
+		s += "[]"
 	}
 	return s
 }
 
+// renderEnumType is the name of the type created for an enum attribute,
+// scoped to its schema since an enum belongs to a single column
+func renderEnumType(attr *model.AttributeRaw) string {
+	return enumTypeName(attr.Parent.Parent.Name, attr.Parent.Name, attr.Name)
+}
+
+func enumTypeName(schema, entity, attr string) string {
+	return fmt.Sprintf("%s.%s_%s", schema, entity, attr)
+}
+
+// renderEnumValues is the quoted list of labels in an enum type
+func renderEnumValues(attr *model.AttributeRaw) string {
+	quoted := make([]string, 0, len(attr.EnumValues))
+	for _, s := range attr.EnumValues {
+		escaped := strings.ReplaceAll(s, "'", "''")
+		quoted = append(quoted, fmt.Sprintf("'%s'", escaped))
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func renderReference(attr *model.Attribute) string {
 	if attr.DirectChild {
 		// unreachable
 		return "???"
 	}
 
-	if attr.ChangedSchema {
-		return fmt.Sprintf("%s.%s(%s)", attr.Final.Parent.Parent.Name, attr.Final.Parent.Name, attr.Final.Name)
+	to := attr.Referenced()
+	if to.Parent != attr.Source.Parent.Parent {
+		return renderQualifiedReference(attr)
+	}
+	return fmt.Sprintf("%s(%s)", to.Name, attr.ReferencedName())
+}
+
+// renderQualifiedReference is what a foreign key references, always with
+// its schema since the table may be altered from another one
+func renderQualifiedReference(attr *model.Attribute) string {
+	to := attr.Referenced()
+	return fmt.Sprintf("%s.%s(%s)", to.Parent.Name, to.Name, attr.ReferencedName())
+}
+
+// renderActions is what a foreign key does as what it references is
+// deleted or updated, nothing if left to the database
+func renderActions(attr *model.Attribute) string {
+	sb := strings.Builder{}
+	if attr.Source.OnDelete != model.RefActionNone {
+		sb.WriteString(" ON DELETE ")
+		sb.WriteString(attr.Source.OnDelete.SQL())
+	}
+	if attr.Source.OnUpdate != model.RefActionNone {
+		sb.WriteString(" ON UPDATE ")
+		sb.WriteString(attr.Source.OnUpdate.SQL())
 	}
-	return fmt.Sprintf("%s(%s)", attr.Final.Parent.Name, attr.Final.Name)
+	return sb.String()
+}
+
+// renderForeignKeyName is the name postgres gives the foreign key of a column
+func renderForeignKeyName(attr *model.Attribute) string {
+	return pgConstraintName(attr.Source.Parent.Name, []string{attr.Name()}, "fkey")
 }
 
 func renderDefault(attr *model.Attribute) string {
@@ -77,7 +137,7 @@ func renderDefault(attr *model.Attribute) string {
 	}
 
 	switch attr.Attribute.Kind {
-	case model.AttrKindString:
+	case model.AttrKindString, model.AttrKindEnum, model.AttrKindText:
 		escaped := strings.ReplaceAll(s, "'", "''")
 		return fmt.Sprintf("'%s'", escaped)
 	case model.AttrKindChar:
@@ -97,18 +157,25 @@ func renderDefault(attr *model.Attribute) string {
 			return "current_timestamp"
 		}
 		return fmt.Sprintf("'%s'", s)
+	case model.AttrKindUUID:
+		if s == "random" {
+			return "gen_random_uuid()"
+		}
+		return fmt.Sprintf("'%s'", s)
+	case model.AttrKindJSON:
+		escaped := strings.ReplaceAll(s, "'", "''")
+		return fmt.Sprintf("'%s'::jsonb", escaped)
+	case model.AttrKindBytes:
+		return fmt.Sprintf("'\\x%s'", s)
 	default:
 		return s
 	}
 }
 
-// PostgresSetup generates a postgres create statements to setup a new database
-func PostgresSetup(schemas []*model.Schema) (map[FileName]string, error) {
-	var err error
-
-	tmpl := template.Template{}
-
-	tmpl.Funcs(template.FuncMap{
+// postgresFuncs are used by the postgres table templates, with the
+// order the tables are created in
+func postgresFuncs(order model.EntityOrder) template.FuncMap {
+	return template.FuncMap{
 		"notLast": func(i int, arr []*model.Attribute) bool {
 			return i != len(arr)-1
 		},
@@ -116,26 +183,54 @@ func PostgresSetup(schemas []*model.Schema) (map[FileName]string, error) {
 		"renderDefault":   renderDefault,
 		"renderErrs":      renderErrs,
 		"renderReference": renderReference,
+		"renderActions":   renderActions,
 		"renderIsNotNull": renderIsNotNull,
-	})
 
-	m := make(map[FileName]string, len(schemas))
-	{
-		_, err = tmpl.ParseGlob("templates/postgres/tables/**")
-		if err != nil {
-			return nil, err
-		}
-		sb := strings.Builder{}
-		err = tmpl.ExecuteTemplate(&sb, "root.tmpl", schemas)
-		if err != nil {
-			return nil, err
-		}
+		"renderEnumType":   renderEnumType,
+		"renderEnumValues": renderEnumValues,
 
-		s := sb.String()
+		"renderQualifiedReference": renderQualifiedReference,
+		"renderForeignKeyName":     renderForeignKeyName,
+		"isDeferred":               order.IsDeferred,
+	}
+}
 
-		if len(s) > 0 {
-			m[newFileName("migrations", fmt.Sprintf("%d.sql", time.Now().Unix()))] = s
-		}
+// orderedSetup is what a setup is templated with,
+// every schema is created before its tables in order
+type orderedSetup struct {
+	Schemas []*model.Schema
+	model.EntityOrder
+}
+
+// PostgresSetup generates a postgres create statements to setup a new database,
+// and the drop statements to tear it down
+func PostgresSetup(schemas []*model.Schema, opts MigrationOptions) (map[FileName]string, error) {
+	order := model.OrderEntities(schemas)
+
+	tmpl, err := parseTemplates(postgresFuncs(order), "postgres/tables/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	data := orderedSetup{
+		Schemas:     schemas,
+		EntityOrder: order,
+	}
+
+	up := strings.Builder{}
+	err = tmpl.ExecuteTemplate(&up, "root.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+
+	down := strings.Builder{}
+	err = tmpl.ExecuteTemplate(&down, "down.tmpl", data)
+	if err != nil {
+		return nil, err
+	}
+
+	if up.Len() == 0 {
+		return make(map[FileName]string), nil
 	}
-	return m, nil
+	return opts.files("setup", up.String(), down.String()), nil
 }
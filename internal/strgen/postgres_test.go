@@ -1,11 +1,12 @@
 package strgen
 
 import (
-	"os"
-	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/Isaac799/devtoolbox/internal/strparse"
+	"github.com/Isaac799/devtoolbox/pkg/model"
 )
 
 const testMockSchemaKitchen = `# Kitchen
@@ -17,15 +18,16 @@ const testMockSchemaKitchen = `# Kitchen
 
 ## Ingredient
 - id          as bit with primary, ..16
-- name        as str  with required, 3..30, unique, d: foo
+- name        as str  with required, 3..30, unique:name, d: foo
 - eol         as date with required, 2006-01-02..2007-03-04
 - rare        as bool with d:false
 - flags       as bit with  ..8
+- storage     as enum(dry, cold, frozen) with required
 - @supplier   with r
 
 ## Food
 - id          as ++
-- name        as str  with required, 3..30, unique
+- name        as str  with required, 3..30, unique:name
 
 ## Recipe
 - @food               with required, primary
@@ -55,29 +57,333 @@ const testMockSchemaFoo = `# Foo
 - @Restaurant.order            with required
 `
 
-const testMockSchemas = testMockSchemaKitchen + "\n" + testMockSchemaRestaurant + "\n" + testMockSchemaFoo
+const testMockSchemaShop = `# Shop
 
-func TestPostgresSetup(t *testing.T) {
-	wd, _ := os.Getwd()
-	os.Chdir("..")
-	os.Chdir("..")
-	defer os.Chdir(wd)
+## Tenant
+- id          as uuid++
+- name        as str  with required, 3..30
+- token       as uuid with unique:token, default:random
+- bio         as text with ..2000
+- settings    as json with required, default:{}
+- logo        as bytes
+
+## Item
+- @tenant             with primary
+- seq         as int  with primary
+- sku         as uuid with required
+- tags        as str[] with ..20
+- sizes       as int[] with 1..12`
+
+// testMockSchemaTree has entities that reference themselves
+const testMockSchemaTree = `# Tree
+
+## Category
+- id          as ++
+- name        as str  with required, 1..30
+- @category   as parent
+
+## Employee
+- k 1         as ++   with primary
+- k 2         as ++   with primary
+- @employee   as manager`
 
+const testMockSchemas = testMockSchemaKitchen + "\n" + testMockSchemaRestaurant + "\n" + testMockSchemaFoo + "\n" + testMockSchemaShop
+
+func TestPostgresSetup(t *testing.T) {
 	schemas := strparse.Raw(testMockSchemas)
 
-	m, err := PostgresSetup(schemas)
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testWriteFiles(t, m)
+}
+
+func TestPostgresEnum(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen)
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, _ := migrations(t, m)
+	typeAt := strings.Index(up, "CREATE TYPE kitchen.ingredient_storage AS ENUM ('dry', 'cold', 'frozen');")
+	tableAt := strings.Index(up, "CREATE TABLE kitchen.ingredient")
+	if typeAt < 0 || typeAt > tableAt {
+		t.Fatal("expected enum type before its table: ", up)
+	}
+	if !strings.Contains(up, "storage kitchen.ingredient_storage NOT NULL") {
+		t.Fatal("expected enum column: ", up)
+	}
+}
+
+func TestPostgresUUID(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaShop)
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, _ := migrations(t, m)
+	for _, s := range []string{
+		"id UUID DEFAULT gen_random_uuid(),",
+		"token UUID DEFAULT gen_random_uuid(),",
+		"tenant_id UUID,",
+		"PRIMARY KEY(tenant_id, seq)",
+	} {
+		if !strings.Contains(up, s) {
+			t.Fatal("expected in migration: ", s)
+		}
+	}
+}
+
+func TestPostgresDocumentKinds(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaShop)
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, _ := migrations(t, m)
+	for _, s := range []string{
+		"bio TEXT,",
+		"settings JSONB DEFAULT '{}'::jsonb NOT NULL,",
+		"logo BYTEA,",
+	} {
+		if !strings.Contains(up, s) {
+			t.Fatal("expected in migration: ", s)
+		}
+	}
+}
+
+func TestPostgresArray(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaShop)
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, _ := migrations(t, m)
+	if !strings.Contains(up, "tags VARCHAR(20)[],") || !strings.Contains(up, "sizes INT[],") {
+		t.Fatal("expected array columns: ", up)
+	}
+}
+
+func TestPostgresOrder(t *testing.T) {
+	schemas := strparse.ParseWith(testMockSchemaKitchen+"\n"+testMockSchemaRestaurant, strparse.Options{Depth: 2}).Schemas
+
+	// schemas and entities declared after what references them
+	schemas[0], schemas[1] = schemas[1], schemas[0]
+	for _, sch := range schemas {
+		slices.Reverse(sch.Entities)
+	}
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	scope := filepath.Join("generated", "pg")
-	os.RemoveAll(scope)
+	up, _ := migrations(t, m)
+	expectInOrder(t, up,
+		"CREATE SCHEMA restaurant;",
+		"CREATE SCHEMA kitchen;",
+		"CREATE TABLE restaurant.customer (",
+		"CREATE TABLE kitchen.food (",
+		"CREATE TABLE kitchen.supplier (",
+		"CREATE TABLE kitchen.ingredient (",
+		"CREATE TABLE kitchen.recipe (",
+		"CREATE TABLE restaurant.order (",
+	)
+	if strings.Contains(up, "ALTER TABLE") {
+		t.Fatal("expected no cycle: ", up)
+	}
+}
+
+func TestPostgresOrderCycle(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen)
+
+	var supplier, ingredient *model.Entity
+	for _, ent := range schemas[0].Entities {
+		switch ent.Name {
+		case "supplier":
+			supplier = ent
+		case "ingredient":
+			ingredient = ent
+		}
+	}
+
+	// supplier and ingredient reference each other
+	attr := model.NewAttribute(supplier)
+	attr.Kind = model.AttrKindReference
+	attr.Name = "ingredient"
+	attr.ReferenceTo = ingredient
+	supplier.RawAttributes = append(supplier.RawAttributes, attr)
+	supplier.ClearCache()
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, down := migrations(t, m)
+	expectInOrder(t, up,
+		"CREATE TABLE kitchen.ingredient (",
+		"CREATE TABLE kitchen.supplier (",
+		"FOREIGN KEY(ingredient_id) REFERENCES ingredient(id)",
+		"ALTER TABLE kitchen.ingredient ADD FOREIGN KEY(supplier_k_1) REFERENCES kitchen.supplier(k_1);",
+		"ALTER TABLE kitchen.ingredient ADD FOREIGN KEY(supplier_k_2) REFERENCES kitchen.supplier(k_2);",
+	)
+	if strings.Contains(up, "FOREIGN KEY(supplier_k_1) REFERENCES supplier(k_1)") {
+		t.Fatal("expected the reference closing the cycle to be deferred: ", up)
+	}
+	expectInOrder(t, down,
+		"ALTER TABLE kitchen.ingredient DROP CONSTRAINT ingredient_supplier_k_1_fkey;",
+		"ALTER TABLE kitchen.ingredient DROP CONSTRAINT ingredient_supplier_k_2_fkey;",
+		"DROP TABLE kitchen.supplier;",
+		"DROP TABLE kitchen.ingredient;",
+	)
+}
+
+func TestPostgresSelfReference(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaTree)
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, _ := migrations(t, m)
+	for _, s := range []string{
+		"parent_id INT,",
+		"FOREIGN KEY(parent_id) REFERENCES category(id)",
+		"manager_k_1 INT,",
+		"FOREIGN KEY(manager_k_2) REFERENCES employee(k_2)",
+	} {
+		if !strings.Contains(up, s) {
+			t.Fatal("expected in migration: ", s)
+		}
+	}
+	if strings.Contains(up, "ALTER TABLE") {
+		t.Fatal("expected a reference to itself not to be a cycle: ", up)
+	}
+}
+
+func TestPostgresActions(t *testing.T) {
+	schemas := strparse.Raw(strings.NewReplacer(
+		"- @food               with required, primary", "- @food               with required, primary, cascade",
+		"- @supplier   with r", "- @supplier   with on delete:set null, on update:cascade",
+	).Replace(testMockSchemaKitchen))
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up, _ := migrations(t, m)
+	for _, s := range []string{
+		"FOREIGN KEY(supplier_k_1) REFERENCES supplier(k_1) ON DELETE SET NULL ON UPDATE CASCADE",
+		"FOREIGN KEY(food_id) REFERENCES food(id) ON DELETE CASCADE",
+		"FOREIGN KEY(ingredient_id) REFERENCES ingredient(id)\n",
+	} {
+		if !strings.Contains(up, s) {
+			t.Fatal("expected in migration: ", s, up)
+		}
+	}
+}
+
+func TestPostgresReferenceDepth(t *testing.T) {
+	schemas := strparse.ParseWith(testMockSchemaKitchen+"\n"+testMockSchemaRestaurant+"\n"+testMockSchemaFoo, strparse.Options{Depth: 3}).Schemas
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a key that is a reference is referenced as named where it is a key
+	up, _ := migrations(t, m)
+	for _, s := range []string{
+		"PRIMARY KEY(customer_id, recipe_food_id, recipe_ingredient_id)",
+		"FOREIGN KEY(recipe_food_id) REFERENCES kitchen.recipe(food_id)",
+		"FOREIGN KEY(recipe_ingredient_id) REFERENCES kitchen.recipe(ingredient_id)",
+		"restaurant_order_recipe_food_id INT NOT NULL,",
+		"FOREIGN KEY(restaurant_order_recipe_food_id) REFERENCES restaurant.order(recipe_food_id)",
+	} {
+		if !strings.Contains(up, s) {
+			t.Fatal("expected in migration: ", s, up)
+		}
+	}
+}
+
+func TestPostgresDown(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen + "\n" + testMockSchemaRestaurant)
+
+	m, err := PostgresSetup(schemas, MigrationOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, down := migrations(t, m)
+	expectInOrder(t, down,
+		"DROP TABLE restaurant.order;",
+		"DROP TABLE restaurant.customer;",
+		"DROP TABLE kitchen.recipe;",
+		"DROP TABLE kitchen.food;",
+		"DROP TABLE kitchen.ingredient;",
+		"DROP TABLE kitchen.supplier;",
+		"DROP TYPE kitchen.ingredient_storage;",
+		"DROP SCHEMA kitchen;",
+		"DROP SCHEMA restaurant;",
+	)
+	if strings.Contains(down, "DROP CONSTRAINT") {
+		t.Fatal("expected no cycle: ", down)
+	}
+}
+
+func TestPostgresMigrationStyle(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen)
+
+	tests := []struct {
+		opts  MigrationOptions
+		files []string
+		marks []string
+	}{
+		{
+			opts:  MigrationOptions{Style: MigrationSequence, Sequence: 7},
+			files: []string{"000007_setup.down.sql", "000007_setup.up.sql"},
+		},
+		{
+			opts:  MigrationOptions{Style: MigrationGoose},
+			marks: []string{"-- +goose Up", "CREATE SCHEMA kitchen;", "-- +goose Down", "DROP SCHEMA kitchen;"},
+		},
+		{
+			opts:  MigrationOptions{Style: MigrationDbmate},
+			marks: []string{"-- migrate:up", "CREATE SCHEMA kitchen;", "-- migrate:down", "DROP SCHEMA kitchen;"},
+		},
+	}
+
+	for _, tt := range tests {
+		m, err := PostgresSetup(schemas, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
 
-	for k, v := range m {
-		dir := filepath.Join(scope, k.Path())
-		name := filepath.Join(scope, k.Full())
+		names := make([]string, 0, len(m))
+		for k, v := range m {
+			names = append(names, k.fileName())
+			expectInOrder(t, v, tt.marks...)
+		}
+		slices.Sort(names)
 
-		os.MkdirAll(dir, os.ModePerm)
-		os.WriteFile(name, []byte(v), os.ModePerm)
+		if tt.files != nil && !slices.Equal(names, tt.files) {
+			t.Fatal("unexpected files: ", names)
+		}
+		if tt.marks != nil && (len(names) != 1 || !strings.HasSuffix(names[0], "_setup.sql")) {
+			t.Fatal("expected a single file: ", names)
+		}
 	}
 }
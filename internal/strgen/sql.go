@@ -0,0 +1,70 @@
+package strgen
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// sqlDialect is how a database is written by a setup that walks its tables
+// the same as any other, with what differs between databases as hooks
+type sqlDialect struct {
+	// name is the directory the setup is written to, under migrations
+	name string
+	// schema is the statement to create a schema, empty if there are none
+	schema func(*model.Schema) string
+	// table is the name of an entity as a table
+	table func(*model.Entity) string
+	// kind is the declared type of a column
+	kind func(*model.Attribute) string
+	// defaultValue is the default of a column that has one
+	defaultValue func(*model.Attribute) string
+	// reference is the table and column a foreign key references
+	reference func(*model.Attribute) string
+	// deferCycles adds a reference closing a cycle once its tables exist,
+	// for a database that checks a reference as its table is created
+	deferCycles bool
+}
+
+// sqlSetup generates the create statements of a dialect to setup a new
+// database. Tables are created in the order of what they reference.
+func sqlSetup(schemas []*model.Schema, dialect sqlDialect) (map[FileName]string, error) {
+	order := model.OrderEntities(schemas)
+	if !dialect.deferCycles {
+		order.Deferred = nil
+	}
+
+	tmpl, err := parseTemplates(template.FuncMap{
+		"notLast": func(i int, arr []*model.Attribute) bool {
+			return i != len(arr)-1
+		},
+		"renderSchema":    dialect.schema,
+		"renderTable":     dialect.table,
+		"renderKind":      dialect.kind,
+		"renderDefault":   dialect.defaultValue,
+		"renderReference": dialect.reference,
+		"renderActions":   renderActions,
+		"renderErrs":      renderErrs,
+		"renderIsNotNull": renderIsNotNull,
+		"isDeferred":      order.IsDeferred,
+	}, "sql/tables/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[FileName]string, 1)
+
+	sb := strings.Builder{}
+	err = tmpl.ExecuteTemplate(&sb, "root.tmpl", orderedSetup{Schemas: schemas, EntityOrder: order})
+	if err != nil {
+		return nil, err
+	}
+
+	if s := sb.String(); len(s) > 0 {
+		m[newFileName("migrations/"+dialect.name, fmt.Sprintf("%d.sql", time.Now().Unix()))] = s
+	}
+	return m, nil
+}
@@ -0,0 +1,181 @@
+package strgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Isaac799/devtoolbox/internal/strparse"
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// testSQLDialect is what a dialect written by sqlSetup is expected to write
+type testSQLDialect struct {
+	name  string
+	setup func([]*model.Schema) (map[FileName]string, error)
+	table func(*model.Entity) string
+	// kitchen and shop are expected in the setup of their schemas
+	kitchen, shop []string
+	// not is never expected in the setup of the kitchen
+	not []string
+	// order is expected in order in the setup of testMockSchemaOrder
+	order []string
+}
+
+// testMockSchemaOrder references forward, and has a cycle
+const testMockSchemaOrder = `# Lib
+
+## Book
+- id as ++
+- @order
+
+## Order
+- id as ++
+
+## Left
+- id as ++
+- @right
+
+## Right
+- id as ++
+- @left`
+
+var testSQLDialects = []testSQLDialect{
+	{
+		name:  "mysql",
+		setup: MySQLSetup,
+		table: func(ent *model.Entity) string { return ent.Parent.Name + "." + ent.Name },
+		kitchen: []string{
+			"CREATE DATABASE IF NOT EXISTS kitchen;",
+			"CREATE TABLE kitchen.ingredient (",
+			"id BIT(16),",
+			"storage ENUM('dry', 'cold', 'frozen') NOT NULL,",
+			"food_id INT NOT NULL,",
+			"UNIQUE(name),",
+			"PRIMARY KEY(food_id, ingredient_id),",
+			"FOREIGN KEY(food_id) REFERENCES kitchen.food(id),",
+		},
+		not: []string{"CREATE SCHEMA", "CREATE TYPE"},
+		shop: []string{
+			"id CHAR(36) DEFAULT (UUID()),",
+			"token CHAR(36) DEFAULT (UUID()),",
+			"settings JSON DEFAULT ('{}') NOT NULL,",
+			"logo BLOB,",
+			"tags JSON,",
+			"FOREIGN KEY(tenant_id) REFERENCES shop.tenant(id)",
+		},
+		order: []string{
+			"CREATE DATABASE IF NOT EXISTS lib;",
+			"CREATE TABLE lib.order (",
+			"CREATE TABLE lib.book (",
+			"FOREIGN KEY(order_id) REFERENCES lib.order(id)",
+			"CREATE TABLE lib.right (",
+			"CREATE TABLE lib.left (",
+			"ALTER TABLE lib.right ADD FOREIGN KEY(left_id) REFERENCES lib.left(id);",
+		},
+	},
+	{
+		name:  "sqlite",
+		setup: SQLiteSetup,
+		table: renderSQLiteTable,
+		kitchen: []string{
+			"CREATE TABLE kitchen_ingredient (",
+			"id TEXT,",
+			"storage TEXT CHECK(storage IN ('dry', 'cold', 'frozen')) NOT NULL,",
+			"food_id INTEGER NOT NULL,",
+			"PRIMARY KEY(food_id, ingredient_id),",
+			"FOREIGN KEY(food_id) REFERENCES kitchen_food(id),",
+		},
+		not: []string{"CREATE SCHEMA", "kitchen."},
+		shop: []string{
+			"id TEXT DEFAULT (lower(hex(randomblob(4)))",
+			"settings TEXT DEFAULT '{}' NOT NULL,",
+			"logo BLOB,",
+			"tags TEXT,",
+			"FOREIGN KEY(tenant_id) REFERENCES shop_tenant(id)",
+		},
+		// a reference is only checked as a record is written
+		order: []string{
+			"CREATE TABLE lib_order (",
+			"CREATE TABLE lib_book (",
+			"CREATE TABLE lib_right (",
+			"FOREIGN KEY(left_id) REFERENCES lib_left(id)",
+			"CREATE TABLE lib_left (",
+		},
+	},
+}
+
+// testSQLSetup is the one file of a setup
+func testSQLSetup(t *testing.T, dialect testSQLDialect, s string) string {
+	t.Helper()
+	m, err := dialect.setup(strparse.Raw(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != 1 {
+		t.Fatal("expected one file: ", m)
+	}
+	for k, v := range m {
+		if k.Path() != "migrations/"+dialect.name {
+			t.Fatal("expected to not overlap with postgres: ", k)
+		}
+		return v
+	}
+	return ""
+}
+
+func TestSQLSetup(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemas)
+
+	for _, dialect := range testSQLDialects {
+		m, err := dialect.setup(schemas)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range m {
+			for _, sch := range schemas {
+				for _, ent := range sch.Entities {
+					if !strings.Contains(v, "CREATE TABLE "+dialect.table(ent)+" (") {
+						t.Fatal(dialect.name, ": expected a table of each entity: ", ent.Name)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSQLKitchen(t *testing.T) {
+	for _, dialect := range testSQLDialects {
+		v := testSQLSetup(t, dialect, testMockSchemaKitchen)
+		for _, s := range dialect.kitchen {
+			if !strings.Contains(v, s) {
+				t.Fatal(dialect.name, ": expected in migration: ", s)
+			}
+		}
+		for _, s := range dialect.not {
+			if strings.Contains(v, s) {
+				t.Fatal(dialect.name, ": expected not in migration: ", s, v)
+			}
+		}
+	}
+}
+
+func TestSQLShop(t *testing.T) {
+	for _, dialect := range testSQLDialects {
+		v := testSQLSetup(t, dialect, testMockSchemaShop)
+		for _, s := range dialect.shop {
+			if !strings.Contains(v, s) {
+				t.Fatal(dialect.name, ": expected in migration: ", s)
+			}
+		}
+	}
+}
+
+func TestSQLOrder(t *testing.T) {
+	for _, dialect := range testSQLDialects {
+		v := testSQLSetup(t, dialect, testMockSchemaOrder)
+		expectInOrder(t, v, dialect.order...)
+		if strings.Count(v, "REFERENCES lib") != 3 {
+			t.Fatal(dialect.name, ": expected each reference once: ", v)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package strgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// _sqliteRandomUUID is a version 4 uuid, since sqlite has no function for one
+const _sqliteRandomUUID = "(lower(hex(randomblob(4))) || '-' || lower(hex(randomblob(2))) || '-4' || substr(lower(hex(randomblob(2))), 2) || '-' || substr('89ab', 1 + (abs(random()) % 4), 1) || substr(lower(hex(randomblob(2))), 2) || '-' || lower(hex(randomblob(6))))"
+
+// _sqliteKind is the declared type of a column. Sqlite only enforces
+// an affinity, so a date or time is declared by name for the driver
+// to parse it, and everything else is text, a number, or a blob.
+var _sqliteKind = map[model.AttrKind]string{
+	model.AttrKindNone:      "???",
+	model.AttrKindReference: "REF",
+	model.AttrKindSerial:    "INTEGER",
+	model.AttrKindInt:       "INTEGER",
+	model.AttrKindChar:      "TEXT",
+	model.AttrKindString:    "TEXT",
+	model.AttrKindBit:       "TEXT",
+	model.AttrKindBoolean:   "BOOLEAN",
+	model.AttrKindDate:      "DATE",
+	model.AttrKindTime:      "TIME",
+	model.AttrKindTimestamp: "DATETIME",
+	model.AttrKindFloat:     "REAL",
+	model.AttrKindReal:      "REAL",
+	model.AttrKindDecimal:   "NUMERIC",
+	model.AttrKindMoney:     "NUMERIC",
+	model.AttrKindEnum:      "TEXT",
+
+	model.AttrKindUUID:          "TEXT",
+	model.AttrKindGeneratedUUID: "TEXT DEFAULT " + _sqliteRandomUUID,
+	model.AttrKindText:          "TEXT",
+	model.AttrKindJSON:          "TEXT",
+	model.AttrKindBytes:         "BLOB",
+}
+
+// renderSQLiteTable is the name of an entity as a table. Sqlite has no
+// schemas, and attached databases cannot reference one another, so
+// the schema is a prefix of the table instead.
+func renderSQLiteTable(ent *model.Entity) string {
+	return fmt.Sprintf("%s_%s", ent.Parent.Name, ent.Name)
+}
+
+func renderSQLiteKind(attr *model.Attribute) string {
+	// there are no array columns, a list is held as text
+	if attr.Final.Array {
+		return _sqliteKind[model.AttrKindText]
+	}
+
+	k := attr.Final.Kind
+
+	if !attr.DirectChild {
+		k = k.Base()
+	}
+
+	s := _sqliteKind[k]
+
+	if k == model.AttrKindEnum {
+		// an enum is a check on its column rather than a type
+		s = fmt.Sprintf("%s CHECK(%s IN (%s))", s, attr.Name(), renderEnumValues(attr.Final))
+	}
+	return s
+}
+
+func renderSQLiteReference(attr *model.Attribute) string {
+	if attr.DirectChild {
+		// unreachable
+		return "???"
+	}
+	return fmt.Sprintf("%s(%s)", renderSQLiteTable(attr.Referenced()), attr.ReferencedName())
+}
+
+// renderSQLiteDefault is the default of a column. A default that is
+// an expression rather than a literal is wrapped in parenthesis.
+func renderSQLiteDefault(attr *model.Attribute) string {
+	s := attr.Attribute.DefaultValue
+	if len(s) == 0 {
+		return ""
+	}
+
+	quoted := fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", "''"))
+
+	switch attr.Attribute.Kind {
+	case model.AttrKindString, model.AttrKindEnum, model.AttrKindChar,
+		model.AttrKindText, model.AttrKindJSON, model.AttrKindBit:
+		return quoted
+	case model.AttrKindBytes:
+		return fmt.Sprintf("x'%s'", s)
+	case model.AttrKindDate:
+		if s == "now" {
+			return "CURRENT_DATE"
+		}
+		return quoted
+	case model.AttrKindTime:
+		if s == "now" {
+			return "CURRENT_TIME"
+		}
+		return quoted
+	case model.AttrKindTimestamp:
+		if s == "now" {
+			return "CURRENT_TIMESTAMP"
+		}
+		return quoted
+	case model.AttrKindUUID:
+		if s == "random" {
+			return _sqliteRandomUUID
+		}
+		return quoted
+	default:
+		return s
+	}
+}
+
+// SQLiteSetup generates sqlite create statements to setup a new database,
+// with each table prefixed by its schema. Sqlite only checks a reference
+// as a record is written, so one closing a cycle is created with its table.
+func SQLiteSetup(schemas []*model.Schema) (map[FileName]string, error) {
+	return sqlSetup(schemas, sqlDialect{
+		name: "sqlite",
+		schema: func(*model.Schema) string {
+			return ""
+		},
+		table:        renderSQLiteTable,
+		kind:         renderSQLiteKind,
+		defaultValue: renderSQLiteDefault,
+		reference:    renderSQLiteReference,
+	})
+}
@@ -3,12 +3,46 @@ package strgen
 
 import (
 	"fmt"
+	"io/fs"
 	"path/filepath"
 	"strings"
+	"text/template"
 
+	"github.com/Isaac799/devtoolbox"
+	"github.com/Isaac799/devtoolbox/pkg/tmplcache"
 	"github.com/iancoleman/strcase"
 )
 
+var (
+	// _templates is where templates are read from
+	_templates = devtoolbox.Templates()
+	// _cache keeps parsed templates between generations
+	_cache = tmplcache.New(false)
+)
+
+// UseTemplates changes where templates are read from, allowing an override
+// directory during development, and the cache parsed templates are kept in.
+// A reloading cache picks up edits without a restart. Not safe to call
+// while generating.
+func UseTemplates(fsys fs.FS, cache *tmplcache.Cache) {
+	_templates = fsys
+	_cache = cache
+}
+
+// parseTemplates provides the templates matching patterns with funcs
+// available, parsing them only when they are not already cached. Funcs
+// are bound again on every call, as they may close over options.
+func parseTemplates(funcs template.FuncMap, patterns ...string) (*template.Template, error) {
+	key := "strgen:" + strings.Join(patterns, ",")
+	tmpl, err := _cache.Get(key, func() (*template.Template, error) {
+		return template.New("").Funcs(funcs).ParseFS(_templates, patterns...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.Funcs(funcs), nil
+}
+
 func renderCamel(s string) string {
 	return strcase.ToLowerCamel(s)
 }
@@ -1,9 +1,27 @@
 package strgen
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 )
 
+// testWriteFiles writes what is generated to a directory removed once
+// the test is done, to be sure each file can be written, providing it
+func testWriteFiles(t *testing.T, m map[FileName]string) string {
+	t.Helper()
+	scope := t.TempDir()
+	for k, v := range m {
+		if err := os.MkdirAll(filepath.Join(scope, k.Path()), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(scope, k.Full()), []byte(v), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return scope
+}
+
 func TestUpperAcronym(t *testing.T) {
 	{
 		s := "foo_bar_id"
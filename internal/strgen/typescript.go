@@ -0,0 +1,184 @@
+package strgen
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+	"github.com/iancoleman/strcase"
+)
+
+var _tsKind = map[model.AttrKind]string{
+	model.AttrKindNone:      "unknown",
+	model.AttrKindReference: "unknown",
+	model.AttrKindSerial:    "number",
+	model.AttrKindInt:       "number",
+	model.AttrKindChar:      "string",
+	model.AttrKindString:    "string",
+	model.AttrKindBit:       "string",
+	model.AttrKindBoolean:   "boolean",
+	model.AttrKindDate:      "string",
+	model.AttrKindTime:      "string",
+	model.AttrKindTimestamp: "string",
+	model.AttrKindFloat:     "number",
+	model.AttrKindReal:      "number",
+	model.AttrKindDecimal:   "number",
+	model.AttrKindMoney:     "number",
+	model.AttrKindEnum:      "string",
+
+	model.AttrKindUUID:          "string",
+	model.AttrKindGeneratedUUID: "string",
+	model.AttrKindText:          "string",
+	model.AttrKindJSON:          "unknown",
+	model.AttrKindBytes:         "string",
+}
+
+// _tsArrayKind is the element of an array for kinds go does not hold as
+// text in a slice, mirroring _goArrayKind
+var _tsArrayKind = map[model.AttrKind]string{
+	model.AttrKindInt:     "number",
+	model.AttrKindBoolean: "boolean",
+	model.AttrKindFloat:   "number",
+	model.AttrKindReal:    "number",
+	model.AttrKindDecimal: "number",
+	model.AttrKindMoney:   "number",
+	model.AttrKindBytes:   "string",
+}
+
+// renderTSKind is the type of an attribute as go encodes it in json
+func renderTSKind(attr *model.Attribute) string {
+	k := attr.Final.Kind
+
+	if !attr.DirectChild {
+		k = k.Base()
+	}
+
+	if attr.Final.Array {
+		if s, ok := _tsArrayKind[k]; ok {
+			return s + "[]"
+		}
+		return "string[]"
+	}
+
+	// an enum type lives in the module of its schema
+	if k == model.AttrKindEnum && !attr.ChangedSchema {
+		return renderGoEnumName(attr.Final)
+	}
+
+	return _tsKind[k]
+}
+
+// renderTSField is the type of a field of a record, able to be null if need be
+func renderTSField(attr *model.Attribute) string {
+	s := renderTSKind(attr)
+	if s == "unknown" || !renderJSONNullable(attr) {
+		return s
+	}
+	return s + " | null"
+}
+
+// renderTSEnumValues is the union of the values an enum may hold
+func renderTSEnumValues(attr *model.AttributeRaw) string {
+	values := make([]string, 0, len(attr.EnumValues))
+	for _, v := range attr.EnumValues {
+		values = append(values, fmt.Sprintf("%q", v))
+	}
+	return strings.Join(values, " | ")
+}
+
+// renderTSHas is the type of what a relation has, qualified by its
+// module if it is of another schema
+func renderTSHas(rel model.Relation) string {
+	s := renderPascalUA(rel.Has.Name)
+	if rel.Has.Parent != rel.Base.Parent {
+		return fmt.Sprintf("%s.%s", packageName(rel.Has.Parent.Name), s)
+	}
+	return s
+}
+
+// renderTSImports are the imports of the module of a schema, the api if it
+// has a client, and the modules of other schemas it has relations to
+func renderTSImports(sch *model.Schema) []string {
+	imports := make([]string, 0)
+	if slices.ContainsFunc(sch.Entities, (*model.Entity).HasPrimary) {
+		imports = append(imports, `import { Api, type Operator } from "./api";`)
+	}
+	seen := make(map[*model.Schema]bool)
+	for _, ent := range sch.Entities {
+		for _, rel := range renderIncludes(ent) {
+			if rel.Has.Parent == sch || seen[rel.Has.Parent] {
+				continue
+			}
+			seen[rel.Has.Parent] = true
+			pn := packageName(rel.Has.Parent.Name)
+			imports = append(imports, fmt.Sprintf(`import type * as %s from "./%s";`, pn, pn))
+		}
+	}
+	return imports
+}
+
+// renderTSKeys are the keys of a record as params of a client method
+func renderTSKeys(ent *model.Entity) string {
+	params := make([]string, 0, len(ent.Primary()))
+	for _, attr := range ent.Primary() {
+		params = append(params, fmt.Sprintf("%s: %s", renderCamel(attr.Name()), renderTSKind(attr)))
+	}
+	return strings.Join(params, ", ")
+}
+
+// renderTSPath is the path of a record as a template literal, built from
+// the keys in scope the same way the handler registers it
+func renderTSPath(ent *model.Entity) string {
+	s := renderPathValues(ent)
+	for _, attr := range ent.Primary() {
+		v := fmt.Sprintf("${encodeURIComponent(%s)}", renderCamel(attr.Name()))
+		s = strings.ReplaceAll(s, fmt.Sprintf("{%s}", attr.Name()), v)
+		s = strings.ReplaceAll(s, fmt.Sprintf("{%s}", strcase.ToSnake(attr.Name())), v)
+	}
+	return fmt.Sprintf("`/%s/%s`", renderKebab(ent.Name), s)
+}
+
+// TypeScript generates typescript types of the records the go handlers
+// serve, and a fetch client of their routes, a module per schema
+func TypeScript(schemas []*model.Schema) (map[FileName]string, error) {
+	tmpl, err := parseTemplates(template.FuncMap{
+		"renderCamel":        renderCamel,
+		"renderPascal":       renderPascalUA,
+		"renderKebab":        renderKebab,
+		"renderGoEnumName":   renderGoEnumName,
+		"renderFilterable":   renderFilterable,
+		"renderIncludes":     renderIncludes,
+		"renderTSKind":       renderTSKind,
+		"renderTSField":      renderTSField,
+		"renderTSEnumValues": renderTSEnumValues,
+		"renderTSHas":        renderTSHas,
+		"renderTSImports":    renderTSImports,
+		"renderTSKeys":       renderTSKeys,
+		"renderTSPath":       renderTSPath,
+	}, "typescript/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[FileName]string, len(schemas)+1)
+
+	sb := strings.Builder{}
+	err = tmpl.ExecuteTemplate(&sb, "api.tmpl", nil)
+	if err != nil {
+		return nil, err
+	}
+	m[newFileName("web/api", "api.ts")] = sb.String()
+
+	for _, s := range schemas {
+		sb := strings.Builder{}
+		err = tmpl.ExecuteTemplate(&sb, "root.tmpl", s)
+		if err != nil {
+			return nil, err
+		}
+		m[newFileName("web/api", fmt.Sprintf("%s.ts", packageName(s.Name)))] = sb.String()
+	}
+
+	return m, nil
+}
@@ -0,0 +1,88 @@
+package strgen
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Isaac799/devtoolbox/internal/strparse"
+)
+
+func TestTypeScript(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemas)
+
+	m, err := TypeScript(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testWriteFiles(t, m)
+}
+
+func TestTypeScriptTypes(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen + "\n" + testMockSchemaShop)
+
+	m, err := TypeScript(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kitchen := m[newFileName("web/api", "kitchen.ts")]
+	for _, s := range []string{
+		`export type IngredientStorage = "dry" | "cold" | "frozen";`,
+		"  storage: IngredientStorage;",
+		"  rare: boolean | null;",
+		"  supplier_k_1: number;",
+		"export interface IngredientSupplier extends Ingredient {\n  supplier: Supplier;\n}",
+		"export interface IngredientFoods extends Ingredient {\n  foods: Food[];\n}",
+	} {
+		if !strings.Contains(kitchen, s) {
+			t.Fatal("expected in kitchen.ts: ", s)
+		}
+	}
+
+	shop := m[newFileName("web/api", "shop.ts")]
+	for _, s := range []string{
+		"  settings: unknown;",
+		"  sizes: number[] | null;",
+		"  tags: string[] | null;",
+	} {
+		if !strings.Contains(shop, s) {
+			t.Fatal("expected in shop.ts: ", s)
+		}
+	}
+
+	m, err = TypeScript(strparse.Raw(testMockSchemaTree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := "  parent: Category | null;"; !strings.Contains(m[newFileName("web/api", "tree.ts")], s) {
+		t.Fatal("expected an optional relation to be null without one: ", s)
+	}
+}
+
+func TestTypeScriptClient(t *testing.T) {
+	schemas := strparse.Raw(testMockSchemaKitchen)
+
+	m, err := TypeScript(schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := m[newFileName("web/api", "api.ts")]; !ok {
+		t.Fatal("expected the shared api module")
+	}
+
+	kitchen := m[newFileName("web/api", "kitchen.ts")]
+	for _, s := range []string{
+		"  get(k1: number, k2: number, include: \"ingredients\"): Promise<SupplierIngredients>;",
+		"`/supplier/k-1/${encodeURIComponent(k1)}/k-2/${encodeURIComponent(k2)}`",
+		"`/recipe/food/${encodeURIComponent(foodId)}/ingredient/${encodeURIComponent(ingredientId)}`",
+		"  op__amount?: Operator;",
+		"  getMany(limit: number, offset: number, filter: RecipeFilter = {}): Promise<Recipe[]> {",
+		"  delete(foodId: number, ingredientId: string): Promise<number> {",
+	} {
+		if !strings.Contains(kitchen, s) {
+			t.Fatal("expected in kitchen.ts: ", s)
+		}
+	}
+}
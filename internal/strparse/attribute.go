@@ -68,12 +68,20 @@ func newAttributeFromLine(s string) *model.AttributeRaw {
 		return &attr
 	}
 
+	// any kind may be listed, 'str[]'
+	kindStr, attr.Array = strings.CutSuffix(kindStr, "[]")
+	kindStr = strings.TrimSpace(kindStr)
+
 	attr.Kind = determineAttrKind(kindStr)
 	if attr.Kind == model.AttrKindNone {
 		attr.AppendErr(ErrKindInvalid)
 		return &attr
 	}
 
+	if attr.Kind == model.AttrKindEnum {
+		attr.EnumValues = enumValues(kindStr)
+	}
+
 	// prevent the case of 'foo.bar' from sneaking past if not a reference
 	if identifierStrHadPeriod && attr.Kind != model.AttrKindReference {
 		identifierStr = identifierStrBefore
@@ -84,6 +92,8 @@ func newAttributeFromLine(s string) *model.AttributeRaw {
 		reqs      = []string{"r", "required"}
 		uniques   = []string{"u", "unique"}
 		defaults  = []string{"d", "default"}
+		deletes   = []string{"on delete"}
+		updates   = []string{"on update"}
 	)
 
 	for _, optRaw := range optsRaw {
@@ -105,6 +115,12 @@ func newAttributeFromLine(s string) *model.AttributeRaw {
 			continue
 		}
 
+		// cascade on its own is what happens as what is referenced is deleted
+		if lowerOpt == "cascade" {
+			attr.OnDelete = model.RefActionCascade
+			continue
+		}
+
 		if strings.Contains(opt, deliRange) {
 			minStr, maxStr, _ := strings.Cut(opt, deliRange)
 			attr.Min = sql.NullString{String: minStr, Valid: len(minStr) > 0}
@@ -114,23 +130,54 @@ func newAttributeFromLine(s string) *model.AttributeRaw {
 
 		if strings.Contains(opt, deliLabel) {
 			str, label, _ := strings.Cut(opt, deliLabel)
+			lowerStr := strings.Join(strings.Fields(strings.ToLower(str)), " ")
 
-			if slices.Contains(uniques, strings.ToLower(str)) {
+			if slices.Contains(uniques, lowerStr) {
 				attr.Unique = append(attr.Unique, strcase.ToSnake(label))
-			} else if slices.Contains(defaults, strings.ToLower(str)) {
+			} else if slices.Contains(defaults, lowerStr) {
 				attr.DefaultValue = label
+			} else if action, ok := model.ParseRefAction(label); ok && slices.Contains(deletes, lowerStr) {
+				attr.OnDelete = action
+			} else if ok && slices.Contains(updates, lowerStr) {
+				attr.OnUpdate = action
+			} else {
+				attr.AppendWarn(fmt.Errorf("%w: %s", model.ErrOptionIgnored, opt))
 			}
 
 			continue
 		}
+
+		attr.AppendWarn(fmt.Errorf("%w: %s", model.ErrOptionIgnored, opt))
 	}
 
 	return &attr
 }
 
+// enumValues provides the values listed in an enum kind,
+// so 'enum(draft, published)' -> ["draft", "published"]
+func enumValues(s string) []string {
+	_, after, _ := strings.Cut(s, "(")
+	inner, _, _ := strings.Cut(after, ")")
+
+	values := make([]string, 0, 4)
+	for _, v := range strings.Split(inner, ",") {
+		values = append(values, strings.TrimSpace(v))
+	}
+	return values
+}
+
 func determineAttrKind(s string) model.AttrKind {
 	kind := model.AttrKindNone
 
+	// enum carries its values with it, 'enum(a, b)'
+	name, args, hasArgs := strings.Cut(s, "(")
+	if strings.EqualFold(strings.TrimSpace(name), "enum") {
+		if hasArgs && !strings.HasSuffix(strings.TrimSpace(args), ")") {
+			return kind
+		}
+		return model.AttrKindEnum
+	}
+
 	switch strings.ToLower(s) {
 	case "++", "auto", "auto increment", "increment":
 		kind = model.AttrKindSerial
@@ -158,6 +205,16 @@ func determineAttrKind(s string) model.AttrKind {
 		kind = model.AttrKindReference
 	case "money":
 		kind = model.AttrKindMoney
+	case "uuid", "guid":
+		kind = model.AttrKindUUID
+	case "uuid++", "auto uuid", "random uuid":
+		kind = model.AttrKindGeneratedUUID
+	case "text":
+		kind = model.AttrKindText
+	case "json", "jsonb":
+		kind = model.AttrKindJSON
+	case "bytes", "bytea", "blob":
+		kind = model.AttrKindBytes
 	}
 	return kind
 }
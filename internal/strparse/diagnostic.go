@@ -0,0 +1,217 @@
+package strparse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// Severity is how serious a diagnostic is
+type Severity int
+
+// recognized severities
+const (
+	SeverityError Severity = iota + 1
+	SeverityWarning
+)
+
+func (sev Severity) String() string {
+	switch sev {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// Code is a stable identifier for the kind of problem a diagnostic describes
+type Code string
+
+// recognized codes
+const (
+	CodeUnknownLine        Code = "unknown-line"
+	CodeOrphanEntity       Code = "orphan-entity"
+	CodeOrphanAttribute    Code = "orphan-attribute"
+	CodeIdentifierRequired Code = "identifier-required"
+	CodeSchemaName         Code = "schema-name"
+	CodeEntityName         Code = "entity-name"
+	CodeKind               Code = "kind"
+	CodeReference          Code = "reference"
+	CodeRange              Code = "range"
+	CodeValidationRequired Code = "validation-required"
+	CodeDefault            Code = "default"
+	CodeReused             Code = "reused"
+	CodeClamped            Code = "clamped"
+	CodeOptionIgnored      Code = "option-ignored"
+	CodeAction             Code = "action"
+	CodeAttribute          Code = "attribute"
+	CodeEmpty              Code = "empty"
+)
+
+// Diagnostic describes a problem found on a single line of input.
+// Line and columns are 1-based, and ColumnEnd is exclusive, so a
+// ux can underline the exact text that caused it.
+type Diagnostic struct {
+	Line      int
+	Column    int
+	ColumnEnd int
+	Severity  Severity
+	Code      Code
+	Message   string
+}
+
+// String provides the diagnostic in a familiar compiler like format
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d-%d: %s[%s]: %s", d.Line, d.Column, d.ColumnEnd, d.Severity, d.Code, d.Message)
+}
+
+// IsErr is a template friendly way to check severity
+func (d Diagnostic) IsErr() bool {
+	return d.Severity == SeverityError
+}
+
+// Result is what is learned from parsing, the schemas and any
+// diagnostics found along the way
+type Result struct {
+	Schemas     []*model.Schema
+	Diagnostics []Diagnostic
+}
+
+// HasErr makes checking for error diagnostics easier
+func (res *Result) HasErr() bool {
+	for _, d := range res.Diagnostics {
+		if d.IsErr() {
+			return true
+		}
+	}
+	return false
+}
+
+// span is a byte range in a trimmed line
+type span struct {
+	start, end int
+}
+
+// sourceLine retains where a line came from so that diagnostics
+// can be made after the model is done being processed
+type sourceLine struct {
+	number int
+	raw    string
+	// offset is the bytes trimmed from the start of raw
+	offset int
+}
+
+func newSourceLine(number int, raw string) sourceLine {
+	trimmed := strings.TrimLeft(raw, " \t\r")
+	return sourceLine{
+		number: number,
+		raw:    raw,
+		offset: len(raw) - len(trimmed),
+	}
+}
+
+func (sl sourceLine) text() string {
+	return strings.TrimSpace(sl.raw)
+}
+
+// whole is a span covering the entire trimmed line
+func (sl sourceLine) whole() span {
+	return span{start: 0, end: len(sl.text())}
+}
+
+func (sl sourceLine) diagnostic(sp span, sev Severity, code Code, msg string) Diagnostic {
+	var (
+		text  = sl.text()
+		start = min(max(sp.start, 0), len(text))
+		end   = min(max(sp.end, start), len(text))
+	)
+
+	col := utf8.RuneCountInString(sl.raw[:sl.offset]) + utf8.RuneCountInString(text[:start]) + 1
+	colEnd := col + utf8.RuneCountInString(text[start:end])
+
+	return Diagnostic{
+		Line:      sl.number,
+		Column:    col,
+		ColumnEnd: colEnd,
+		Severity:  sev,
+		Code:      code,
+		Message:   msg,
+	}
+}
+
+// attrSpans breaks an attribute line into its identifier, kind and options
+// spans. A missing part is an empty span at the point it was expected.
+func attrSpans(text string) (ident, kind, opts span) {
+	rest := len(prefixAttr)
+	end := len(text)
+
+	withAt := strings.Index(text, deliWith)
+	if withAt >= 0 {
+		opts = span{start: withAt + len(deliWith), end: end}
+		end = withAt
+	} else {
+		opts = span{start: end, end: end}
+	}
+
+	asAt := strings.Index(text[:end], deliAs)
+	if asAt >= 0 {
+		ident = span{start: rest, end: asAt}
+		kind = span{start: asAt + len(deliAs), end: end}
+	} else {
+		ident = span{start: rest, end: end}
+		kind = span{start: end, end: end}
+	}
+
+	// references have no kind, point at what is referenced
+	if strings.HasPrefix(text[rest:], "@") {
+		kind = ident
+	}
+
+	return ident, kind, opts
+}
+
+// attrErrDiagnostic classifies an attribute error into a code
+// and the part of the line that caused it
+func attrErrDiagnostic(sl sourceLine, err error, sev Severity) Diagnostic {
+	ident, kind, opts := attrSpans(sl.text())
+
+	var (
+		code = CodeAttribute
+		sp   = sl.whole()
+	)
+
+	switch {
+	case errors.Is(err, ErrIdentifierRequired):
+		code, sp = CodeIdentifierRequired, ident
+	case errors.Is(err, ErrKindRequired), errors.Is(err, ErrKindInvalid), errors.Is(err, model.ErrPrimaryKind), errors.Is(err, model.ErrArrayKind):
+		code, sp = CodeKind, kind
+	case errors.Is(err, model.ErrReference):
+		code, sp = CodeReference, ident
+	case errors.Is(err, model.ErrRangeMinMalformed),
+		errors.Is(err, model.ErrRangeMaxMalformed),
+		errors.Is(err, model.ErrRangeMaxUnderMin),
+		errors.Is(err, model.ErrRangeMaxBelowZero):
+		code, sp = CodeRange, opts
+	case errors.Is(err, model.ErrMaxLenRequired), errors.Is(err, model.ErrBitSizeRequired), errors.Is(err, model.ErrEnumRequired):
+		code, sp = CodeValidationRequired, kind
+	case errors.Is(err, model.ErrEnumReused):
+		code, sp = CodeReused, kind
+	case errors.Is(err, model.ErrMalformedDefault):
+		code, sp = CodeDefault, opts
+	case errors.Is(err, model.ErrReusedName), errors.Is(err, model.ErrReusedAlias), errors.Is(err, model.ErrOptionAttribute):
+		code, sp = CodeReused, ident
+	case errors.Is(err, model.ErrGeneratedClamped):
+		code, sp = CodeClamped, opts
+	case errors.Is(err, model.ErrOptionIgnored):
+		code, sp = CodeOptionIgnored, opts
+	case errors.Is(err, model.ErrSetNullRequired):
+		code, sp = CodeAction, opts
+	}
+
+	return sl.diagnostic(sp, sev, code, err.Error())
+}
@@ -1,18 +1,23 @@
 package strparse
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Isaac799/devtoolbox/internal"
 	"github.com/Isaac799/devtoolbox/pkg/model"
 )
 
-func newEntFromLine(s string) (*model.Entity, error) {
+// newEntFromLine provides the entity of a line, and a warning
+// for each of its options that is not recognized
+func newEntFromLine(s string) (*model.Entity, []error, error) {
 	noPrefix := strings.TrimPrefix(s, prefixEnt)
 
 	var (
 		name, optsStr, _ = strings.Cut(noPrefix, deliWith)
 		optsRaw          = strings.Split(optsStr, ",")
+		warns            = make([]error, 0)
 	)
 
 	ent := model.Entity{
@@ -20,6 +25,16 @@ func newEntFromLine(s string) (*model.Entity, error) {
 		RawAttributes: make([]*model.AttributeRaw, 0, 10),
 	}
 
+	if len(ent.Name) == 0 {
+		return nil, nil, ErrIdentifierRequired
+	}
+
+	var (
+		timestamps  = []string{"timestamps"}
+		softDeletes = []string{"soft delete"}
+		versions    = []string{"versioned"}
+	)
+
 	for _, optRaw := range optsRaw {
 		opt := strings.TrimSpace(optRaw)
 
@@ -27,13 +42,19 @@ func newEntFromLine(s string) (*model.Entity, error) {
 			continue
 		}
 
-		if strings.Contains(opt, deliLabel) {
-			str, label, _ := strings.Cut(opt, deliLabel)
-			_ = str
-			_ = label
-			break
+		lowerOpt := strings.Join(strings.Fields(strings.ToLower(opt)), " ")
+
+		switch {
+		case slices.Contains(timestamps, lowerOpt):
+			ent.Timestamps = true
+		case slices.Contains(softDeletes, lowerOpt):
+			ent.SoftDelete = true
+		case slices.Contains(versions, lowerOpt):
+			ent.Versioned = true
+		default:
+			warns = append(warns, fmt.Errorf("%w: %s", model.ErrOptionIgnored, opt))
 		}
 	}
 
-	return &ent, nil
+	return &ent, warns, nil
 }
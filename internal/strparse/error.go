@@ -7,5 +7,9 @@ var (
 	ErrIdentifierRequired = errors.New("identifier required")
 	ErrKindRequired       = errors.New("kind required")
 	ErrKindInvalid        = errors.New("kind invalid")
-	ErrReusedAlias        = errors.New("alias already used")
+	ErrOrphanEntity       = errors.New("entity has no schema")
+	ErrOrphanAttribute    = errors.New("attribute has no entity")
+	ErrUnknownLine        = errors.New("line not recognized")
+	ErrUnknownStatement   = errors.New("statement not recognized")
+	ErrForeignKeyIgnored  = errors.New("foreign key not on a primary key")
 )
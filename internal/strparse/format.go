@@ -0,0 +1,72 @@
+package strparse
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// Format provides the text that parses to the schemas
+func Format(schemas []*model.Schema) string {
+	sb := strings.Builder{}
+
+	for si, schema := range schemas {
+		if si > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(schema.String())
+		sb.WriteString("\n")
+		sb.WriteString("\n")
+		for ei, entity := range schema.Entities {
+			if ei > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(entity.String())
+			sb.WriteString("\n")
+			sb.WriteString("\n")
+			for _, attr := range entity.RawAttributes {
+				// what an option adds is written as the option
+				if attr.Managed {
+					continue
+				}
+				sb.WriteString(attr.String())
+				sb.WriteString("\n")
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+// sortByReference sorts imported schemas and their entities so what is
+// referenced is written first where it can be, since references are
+// resolved in the order they are written. Relations are then set.
+func sortByReference(schemas []*model.Schema) {
+	order := model.OrderEntities(schemas)
+	rank := func(ent *model.Entity) int {
+		return slices.Index(order.Entities, ent)
+	}
+	first := func(sch *model.Schema) int {
+		n := len(order.Entities)
+		for _, ent := range sch.Entities {
+			n = min(n, rank(ent))
+		}
+		return n
+	}
+	for _, sch := range schemas {
+		slices.SortStableFunc(sch.Entities, func(a, b *model.Entity) int {
+			return rank(a) - rank(b)
+		})
+	}
+	slices.SortStableFunc(schemas, func(a, b *model.Schema) int {
+		return first(a) - first(b)
+	})
+
+	for _, sch := range schemas {
+		for _, ent := range sch.Entities {
+			ent.ClearCache()
+			ent.SetRelations(schemas)
+		}
+	}
+}
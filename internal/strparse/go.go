@@ -0,0 +1,466 @@
+package strparse
+
+import (
+	"database/sql"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Isaac799/devtoolbox/internal"
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// goType is what a field type is held as
+type goType struct {
+	kind model.AttrKind
+	// optional is true if the field may be nil or null
+	optional bool
+	array    bool
+	enum     []string
+	// entity is the struct of the package it references
+	entity string
+}
+
+// goImport is what is learned of a package from its source
+type goImport struct {
+	fset    *token.FileSet
+	lines   map[string][]string
+	structs map[string]*ast.StructType
+	// names are the exported structs in the order they are declared
+	names []string
+	// named are the types that are not structs, 'type Mood string'
+	named       map[string]ast.Expr
+	enums       map[string][]string
+	diagnostics []Diagnostic
+}
+
+func (imp *goImport) diagnose(pos token.Pos, sev Severity, code Code, msg string) {
+	p := imp.fset.Position(pos)
+	raw := ""
+	if lines := imp.lines[p.Filename]; p.Line > 0 && p.Line <= len(lines) {
+		raw = lines[p.Line-1]
+	}
+	sl := newSourceLine(p.Line, raw)
+	msg = fmt.Sprintf("%s: %s", filepath.Base(p.Filename), msg)
+	imp.diagnostics = append(imp.diagnostics, sl.diagnostic(sl.whole(), sev, code, msg))
+}
+
+// declare learns the types and enum values of a file
+func (imp *goImport) declare(file *ast.File) {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				if st, ok := spec.Type.(*ast.StructType); ok {
+					imp.structs[spec.Name.Name] = st
+					if spec.Name.IsExported() {
+						imp.names = append(imp.names, spec.Name.Name)
+					}
+					continue
+				}
+				imp.named[spec.Name.Name] = spec.Type
+			case *ast.ValueSpec:
+				// an enum is a string type with constants of its values
+				ident, ok := spec.Type.(*ast.Ident)
+				if gen.Tok != token.CONST || !ok {
+					continue
+				}
+				for _, v := range spec.Values {
+					lit, ok := v.(*ast.BasicLit)
+					if !ok || lit.Kind != token.STRING {
+						continue
+					}
+					s, err := strconv.Unquote(lit.Value)
+					if err != nil {
+						continue
+					}
+					imp.enums[ident.Name] = append(imp.enums[ident.Name], s)
+				}
+			}
+		}
+	}
+}
+
+// embeds are the structs of the package a struct embeds
+func (imp *goImport) embeds(st *ast.StructType) []string {
+	names := make([]string, 0, 1)
+	for _, field := range st.Fields.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+		if name := imp.structName(field.Type); len(name) > 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// structName is the struct of the package a type is, or holds
+func (imp *goImport) structName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		return imp.structName(expr.X)
+	case *ast.ArrayType:
+		return imp.structName(expr.Elt)
+	case *ast.Ident:
+		if _, ok := imp.structs[expr.Name]; ok {
+			return expr.Name
+		}
+	}
+	return ""
+}
+
+// isView is true of a struct that embeds another to add what it is
+// related to, such as a list of what has it, rather than columns
+func (imp *goImport) isView(st *ast.StructType) bool {
+	if len(imp.embeds(st)) == 0 {
+		return false
+	}
+	for _, field := range st.Fields.List {
+		if len(field.Names) > 0 && len(imp.structName(field.Type)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// entities are the exported structs that are an entity. A struct
+// embedded for its fields, or that is a view of another, is not one.
+func (imp *goImport) entities() []string {
+	mixins := make([]string, 0)
+	for _, st := range imp.structs {
+		if imp.isView(st) {
+			continue
+		}
+		mixins = append(mixins, imp.embeds(st)...)
+	}
+
+	names := make([]string, 0, len(imp.names))
+	for _, name := range imp.names {
+		if slices.Contains(mixins, name) || imp.isView(imp.structs[name]) {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// resolve is how a type is held, false if it cannot be
+func (imp *goImport) resolve(expr ast.Expr, depth int) (goType, bool) {
+	if depth > 8 {
+		return goType{}, false
+	}
+
+	switch expr := expr.(type) {
+	case *ast.StarExpr:
+		typ, ok := imp.resolve(expr.X, depth+1)
+		typ.optional = true
+		return typ, ok
+	case *ast.ArrayType:
+		if ident, ok := expr.Elt.(*ast.Ident); ok && ident.Name == "byte" {
+			return goType{kind: model.AttrKindBytes, optional: true}, true
+		}
+		typ, ok := imp.resolve(expr.Elt, depth+1)
+		if typ.array || expr.Len != nil {
+			return typ, false
+		}
+		typ.array = true
+		typ.optional = true
+		return typ, ok
+	case *ast.MapType, *ast.InterfaceType:
+		return goType{kind: model.AttrKindJSON, optional: true}, true
+	case *ast.IndexExpr:
+		// a generic null, 'Null[T]' or 'sql.Null[T]'
+		typ, ok := imp.resolve(expr.Index, depth+1)
+		typ.optional = true
+		return typ, ok && goTypeName(expr.X) == "Null"
+	case *ast.SelectorExpr:
+		return goSelectorType(expr)
+	case *ast.Ident:
+		switch expr.Name {
+		case "string":
+			return goType{kind: model.AttrKindText}, true
+		case "bool":
+			return goType{kind: model.AttrKindBoolean}, true
+		case "int", "int8", "int16", "int32", "int64",
+			"uint", "uint8", "uint16", "uint32", "uint64", "byte":
+			return goType{kind: model.AttrKindInt}, true
+		case "rune":
+			return goType{kind: model.AttrKindChar}, true
+		case "float32":
+			return goType{kind: model.AttrKindReal}, true
+		case "float64":
+			return goType{kind: model.AttrKindFloat}, true
+		case "any":
+			return goType{kind: model.AttrKindJSON, optional: true}, true
+		}
+		if _, ok := imp.structs[expr.Name]; ok {
+			return goType{kind: model.AttrKindReference, entity: expr.Name}, true
+		}
+		if values, ok := imp.enums[expr.Name]; ok {
+			return goType{kind: model.AttrKindEnum, enum: values}, true
+		}
+		if under, ok := imp.named[expr.Name]; ok {
+			return imp.resolve(under, depth+1)
+		}
+	}
+	return goType{}, false
+}
+
+// goTypeName is the name of a type without its package
+func goTypeName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.SelectorExpr:
+		return expr.Sel.Name
+	}
+	return ""
+}
+
+// goSelectorType is how a type of another package is held
+func goSelectorType(expr *ast.SelectorExpr) (goType, bool) {
+	pkg := goTypeName(expr.X)
+
+	switch expr.Sel.Name {
+	case "UUID":
+		return goType{kind: model.AttrKindUUID}, true
+	case "Decimal":
+		return goType{kind: model.AttrKindDecimal}, true
+	case "RawMessage":
+		return goType{kind: model.AttrKindJSON}, true
+	}
+
+	switch pkg + "." + expr.Sel.Name {
+	case "time.Time":
+		return goType{kind: model.AttrKindTimestamp}, true
+	case "time.Duration":
+		return goType{kind: model.AttrKindInt}, true
+	case "sql.NullString":
+		return goType{kind: model.AttrKindText, optional: true}, true
+	case "sql.NullInt64", "sql.NullInt32", "sql.NullInt16", "sql.NullByte":
+		return goType{kind: model.AttrKindInt, optional: true}, true
+	case "sql.NullFloat64":
+		return goType{kind: model.AttrKindFloat, optional: true}, true
+	case "sql.NullBool":
+		return goType{kind: model.AttrKindBoolean, optional: true}, true
+	case "sql.NullTime":
+		return goType{kind: model.AttrKindTimestamp, optional: true}, true
+	}
+	return goType{}, false
+}
+
+// goColumn is the column a field is named by its tags, with the db tag
+// before the json tag, or its name. False if a tag omits it.
+func goColumn(field *ast.Field, name string) (string, bool) {
+	if field.Tag != nil {
+		s, _ := strconv.Unquote(field.Tag.Value)
+		tag := reflect.StructTag(s)
+		for _, key := range []string{"db", "json"} {
+			v, ok := tag.Lookup(key)
+			if !ok {
+				continue
+			}
+			col, _, _ := strings.Cut(v, ",")
+			if col == "-" {
+				return "", false
+			}
+			if len(col) > 0 {
+				return internal.Normalize(col), true
+			}
+		}
+	}
+	return internal.Normalize(name), true
+}
+
+// fields are the named fields of a struct, with those of any struct
+// it embeds in their place
+func (imp *goImport) fields(st *ast.StructType, depth int) []*ast.Field {
+	fields := make([]*ast.Field, 0, len(st.Fields.List))
+	for _, field := range st.Fields.List {
+		if len(field.Names) > 0 {
+			fields = append(fields, field)
+			continue
+		}
+		name := imp.structName(field.Type)
+		if len(name) == 0 || depth > 8 {
+			continue
+		}
+		fields = append(fields, imp.fields(imp.structs[name], depth+1)...)
+	}
+	return fields
+}
+
+func (imp *goImport) attributes(ent *model.Entity, st *ast.StructType, byName map[string]*model.Entity) {
+	for _, field := range imp.fields(st, 0) {
+		for _, ident := range field.Names {
+			if !ident.IsExported() {
+				continue
+			}
+			col, ok := goColumn(field, ident.Name)
+			if !ok {
+				continue
+			}
+
+			typ, ok := imp.resolve(field.Type, 0)
+			if !ok {
+				imp.diagnose(field.Pos(), SeverityWarning, CodeKind, fmt.Sprintf("%s: %s", ErrKindInvalid, ident.Name))
+				continue
+			}
+
+			attr := model.NewAttribute(ent)
+			attr.Name = col
+			attr.Kind = typ.kind
+			attr.Array = typ.array
+			attr.EnumValues = typ.enum
+			if !typ.optional {
+				attr.Required = sql.NullBool{Bool: true, Valid: true}
+			}
+
+			// a key named for an entity, 'SupplierID', references it
+			prefix, isID := strings.CutSuffix(ident.Name, "ID")
+			if to, ok := byName[prefix]; ok && isID && !typ.array && to != ent {
+				typ.entity = prefix
+			}
+
+			switch {
+			case len(typ.entity) > 0:
+				if typ.array {
+					// a list of what has this is a relation, not a column
+					continue
+				}
+				to := byName[typ.entity]
+				if to == nil {
+					imp.diagnose(field.Pos(), SeverityWarning, CodeReference, fmt.Sprintf("%s: %s", model.ErrReference, typ.entity))
+					continue
+				}
+				attr.Kind = model.AttrKindReference
+				attr.ReferenceTo = to
+				attr.Name = to.Name
+				// named for something other than the entity, 'Owner *Customer'
+				if alias := internal.Normalize(strings.TrimSuffix(ident.Name, "ID")); alias != to.Name {
+					attr.Alias = alias
+				}
+				// a struct and its key are the same reference
+				if slices.ContainsFunc(ent.RawAttributes, func(a *model.AttributeRaw) bool {
+					return a.ReferenceTo == to && a.Alias == attr.Alias
+				}) {
+					continue
+				}
+			case col == "id":
+				attr.Primary = true
+				switch attr.Kind {
+				case model.AttrKindInt:
+					attr.Kind = model.AttrKindSerial
+				case model.AttrKindUUID:
+					attr.Kind = model.AttrKindGeneratedUUID
+				}
+			}
+
+			attr.SanitizeEnumValues()
+			attr.EnsureValidRange()
+			attr.MaybeRequireValidation()
+			attr.EnsureValidPrimary()
+			attr.EnsureValidArray()
+			attr.EnsureValidAction()
+			attr.SanativeGeneratedKind()
+
+			for _, err := range attr.Err {
+				imp.diagnose(field.Pos(), SeverityError, CodeAttribute, fmt.Sprintf("%s: %s", ident.Name, err))
+			}
+
+			ent.RawAttributes = append(ent.RawAttributes, attr)
+		}
+	}
+}
+
+// Go reads the structs of a go package directory and provides the schema
+// they describe, named for the package. Each exported struct with a field
+// that can be a column is an entity, with a field of another entity, or
+// its key such as 'SupplierID', as a reference to it.
+func Go(dir string) (*Result, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	imp := goImport{
+		fset:        token.NewFileSet(),
+		lines:       make(map[string][]string),
+		structs:     make(map[string]*ast.StructType),
+		names:       make([]string, 0, 10),
+		named:       make(map[string]ast.Expr),
+		enums:       make(map[string][]string),
+		diagnostics: make([]Diagnostic, 0),
+	}
+
+	pkg := ""
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := parser.ParseFile(imp.fset, path, b, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		imp.lines[path] = strings.Split(string(b), "\n")
+		pkg = file.Name.Name
+		imp.declare(file)
+	}
+
+	if len(pkg) == 0 {
+		return nil, fmt.Errorf("no go files in %s", dir)
+	}
+
+	sch := model.NewSchema()
+	sch.Name = internal.Normalize(pkg)
+
+	names := imp.entities()
+	byName := make(map[string]*model.Entity, len(names))
+	for _, name := range names {
+		ent := model.NewEntity(sch)
+		ent.Name = internal.Normalize(name)
+		byName[name] = ent
+	}
+
+	for _, name := range names {
+		imp.attributes(byName[name], imp.structs[name], byName)
+	}
+	for _, name := range names {
+		ent := byName[name]
+		// a struct without any columns, like a store, is not an entity
+		if len(ent.RawAttributes) == 0 {
+			continue
+		}
+		sch.Entities = append(sch.Entities, ent)
+	}
+
+	schemas := []*model.Schema{sch}
+	sortByReference(schemas)
+
+	slices.SortStableFunc(imp.diagnostics, func(a, b Diagnostic) int {
+		return a.Line - b.Line
+	})
+
+	return &Result{
+		Schemas:     schemas,
+		Diagnostics: imp.diagnostics,
+	}, nil
+}
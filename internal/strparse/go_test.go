@@ -0,0 +1,167 @@
+package strparse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+const testMockGoShop = `package shop
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Mood string
+
+const (
+	MoodHappy Mood = "happy"
+	MoodSad   Mood = "sad"
+)
+
+// Stamped is embedded for its fields
+type Stamped struct {
+	CreatedAt time.Time  ` + "`db:\"created_at\"`" + `
+	DeletedAt *time.Time ` + "`db:\"deleted_at\"`" + `
+}
+
+type Customer struct {
+	ID        int            ` + "`json:\"id\"`" + `
+	FirstName string         ` + "`json:\"first_name\"`" + `
+	Nickname  sql.NullString ` + "`db:\"nick\" json:\"nickname\"`" + `
+	Mood      Mood
+	Tags      []string
+	Secret    string ` + "`json:\"-\"`" + `
+	internal  string
+	Stamped
+}
+
+type Supplier struct {
+	ID   uuid.UUID
+	Name string
+}
+
+type Item struct {
+	ID         int
+	Owner      *Customer
+	SupplierID uuid.UUID
+	Supplier   *Supplier
+	Price      float64
+	Logo       []byte
+	Settings   map[string]any
+	Meta       json.RawMessage
+	Done       chan bool
+}
+
+// CustomerItems is a view of a customer, not an entity
+type CustomerItems struct {
+	Customer
+	Items []Item
+}
+
+// ItemStore has nothing that is a column
+type ItemStore struct {
+	db *sql.DB
+}
+`
+
+func TestGo(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "shop.go"), []byte(testMockGoShop), os.ModePerm)
+	os.WriteFile(filepath.Join(dir, "shop_test.go"), []byte("package shop\n\ntype Fixture struct{ ID int }\n"), os.ModePerm)
+
+	result, err := Go(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemas := result.Schemas
+	testNoErr(t, schemas)
+
+	if len(schemas) != 1 || schemas[0].Name != "shop" {
+		t.Fatal("expected a schema named for the package")
+	}
+	names := make([]string, 0, 3)
+	for _, ent := range schemas[0].Entities {
+		names = append(names, ent.Name)
+	}
+	if len(names) != 3 || names[0] != "customer" || names[1] != "supplier" || names[2] != "item" {
+		t.Fatal("expected entities without mixins, views, or stores: ", names)
+	}
+
+	customer := testEntity(t, schemas, "shop", "customer")
+	if id := testAttribute(t, customer, "id"); id.Kind != model.AttrKindSerial || !id.Primary {
+		t.Fatal("expected serial key: ", id.String())
+	}
+	if first := testAttribute(t, customer, "first_name"); first.Kind != model.AttrKindText || !first.Required.Bool {
+		t.Fatal("expected required text: ", first.String())
+	}
+	if nick := testAttribute(t, customer, "nick"); nick.Required.Bool {
+		t.Fatal("expected db tag before json, and null as optional: ", nick.String())
+	}
+	if mood := testAttribute(t, customer, "mood"); mood.Kind != model.AttrKindEnum || mood.EnumLabels() != "happy, sad" {
+		t.Fatal("expected enum from constants: ", mood.String())
+	}
+	if tags := testAttribute(t, customer, "tags"); !tags.Array {
+		t.Fatal("expected array: ", tags.String())
+	}
+	if deleted := testAttribute(t, customer, "deleted_at"); deleted.Kind != model.AttrKindTimestamp || deleted.Required.Bool {
+		t.Fatal("expected embedded fields: ", deleted.String())
+	}
+	for _, attr := range customer.RawAttributes {
+		if attr.Name == "secret" || attr.Name == "internal" {
+			t.Fatal("expected omitted and unexported fields to be skipped: ", attr.Name)
+		}
+	}
+
+	if id := testAttribute(t, testEntity(t, schemas, "shop", "supplier"), "id"); id.Kind != model.AttrKindGeneratedUUID {
+		t.Fatal("expected generated uuid key: ", id.String())
+	}
+
+	item := testEntity(t, schemas, "shop", "item")
+	owner := testAttribute(t, item, "customer")
+	if owner.Alias != "owner" || owner.Required.Bool {
+		t.Fatal("expected optional reference as owner: ", owner.String())
+	}
+	// the key and struct of the supplier are the same reference
+	refs := 0
+	for _, attr := range item.RawAttributes {
+		if attr.ReferenceTo != nil && attr.ReferenceTo.Name == "supplier" {
+			refs++
+		}
+	}
+	if refs != 1 {
+		t.Fatal("expected one reference to supplier: ", refs)
+	}
+	for _, tc := range []struct {
+		name string
+		kind model.AttrKind
+	}{
+		{"price", model.AttrKindFloat},
+		{"logo", model.AttrKindBytes},
+		{"settings", model.AttrKindJSON},
+		{"meta", model.AttrKindJSON},
+	} {
+		if attr := testAttribute(t, item, tc.name); attr.Kind != tc.kind {
+			t.Fatal("unexpected kind: ", attr.String())
+		}
+	}
+
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Code != CodeKind {
+		t.Fatal("expected the channel to be skipped: ", result.Diagnostics)
+	}
+
+	testRoundTrip(t, schemas)
+}
+
+func TestGoEmpty(t *testing.T) {
+	if _, err := Go(t.TempDir()); err == nil {
+		t.Fatal("expected err without go files")
+	}
+}
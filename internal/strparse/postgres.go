@@ -0,0 +1,1050 @@
+package strparse
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Isaac799/devtoolbox/internal"
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// _pgPublic is the schema of a name that is not qualified
+const _pgPublic = "public"
+
+type pgTokenKind int
+
+const (
+	pgTokenWord pgTokenKind = iota
+	pgTokenIdent
+	pgTokenString
+	pgTokenSymbol
+)
+
+// pgToken is a word, quoted identifier, literal, or symbol of ddl
+type pgToken struct {
+	kind pgTokenKind
+	text string
+	line int
+}
+
+// is checks a token is a keyword, ignoring case
+func (tok pgToken) is(keywords ...string) bool {
+	if tok.kind != pgTokenWord {
+		return false
+	}
+	for _, k := range keywords {
+		if strings.EqualFold(tok.text, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// ident is the name a token refers to. Postgres folds
+// a name to lowercase unless it was quoted.
+func (tok pgToken) ident() string {
+	if tok.kind == pgTokenWord {
+		return strings.ToLower(tok.text)
+	}
+	return tok.text
+}
+
+func isPgWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// pgTokenize breaks ddl into tokens, dropping comments
+func pgTokenize(s string) []pgToken {
+	var (
+		tokens = make([]pgToken, 0, len(s)/4)
+		rs     = []rune(s)
+		line   = 1
+	)
+
+	// quoted reads until an unescaped end, where a doubled end is an escape
+	quoted := func(i int, end rune) (string, int) {
+		sb := strings.Builder{}
+		for i++; i < len(rs); i++ {
+			if rs[i] == '\n' {
+				line++
+			}
+			if rs[i] == end {
+				if i+1 < len(rs) && rs[i+1] == end {
+					sb.WriteRune(end)
+					i++
+					continue
+				}
+				return sb.String(), i + 1
+			}
+			sb.WriteRune(rs[i])
+		}
+		return sb.String(), i
+	}
+
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		start := line
+
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			i += 2
+			for i < len(rs) && !(rs[i] == '*' && i+1 < len(rs) && rs[i+1] == '/') {
+				if rs[i] == '\n' {
+					line++
+				}
+				i++
+			}
+			i += 2
+		case r == '\'':
+			var text string
+			text, i = quoted(i, '\'')
+			tokens = append(tokens, pgToken{kind: pgTokenString, text: text, line: start})
+		case r == '"':
+			var text string
+			text, i = quoted(i, '"')
+			tokens = append(tokens, pgToken{kind: pgTokenIdent, text: text, line: start})
+		case r == '$':
+			// a dollar quoted body, as a function has, is a single literal
+			j := i + 1
+			for j < len(rs) && rs[j] != '$' && isPgWordRune(rs[j]) {
+				j++
+			}
+			if j >= len(rs) || rs[j] != '$' {
+				tokens = append(tokens, pgToken{kind: pgTokenSymbol, text: "$", line: start})
+				i++
+				continue
+			}
+			tag := string(rs[i : j+1])
+			body, _, _ := strings.Cut(string(rs[j+1:]), tag)
+			line += strings.Count(body, "\n")
+			tokens = append(tokens, pgToken{kind: pgTokenString, text: body, line: start})
+			i = j + 1 + len([]rune(body)) + len([]rune(tag))
+		case isPgWordRune(r):
+			j := i
+			for j < len(rs) && isPgWordRune(rs[j]) {
+				j++
+				// a number may have a fraction, '1.5'
+				if unicode.IsDigit(r) && j+1 < len(rs) && rs[j] == '.' && unicode.IsDigit(rs[j+1]) {
+					j++
+				}
+			}
+			tokens = append(tokens, pgToken{kind: pgTokenWord, text: string(rs[i:j]), line: start})
+			i = j
+		case r == ':' && i+1 < len(rs) && rs[i+1] == ':':
+			tokens = append(tokens, pgToken{kind: pgTokenSymbol, text: "::", line: start})
+			i += 2
+		default:
+			tokens = append(tokens, pgToken{kind: pgTokenSymbol, text: string(r), line: start})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// pgStatement is the tokens of a single statement, read from front to back
+type pgStatement struct {
+	tokens []pgToken
+	at     int
+}
+
+func (st *pgStatement) done() bool {
+	return st.at >= len(st.tokens)
+}
+
+func (st *pgStatement) peek() pgToken {
+	if st.done() {
+		return pgToken{kind: pgTokenSymbol}
+	}
+	return st.tokens[st.at]
+}
+
+func (st *pgStatement) next() pgToken {
+	tok := st.peek()
+	st.at++
+	return tok
+}
+
+// keyword consumes a run of keywords if they are next
+func (st *pgStatement) keyword(keywords ...string) bool {
+	for i, k := range keywords {
+		if st.at+i >= len(st.tokens) || !st.tokens[st.at+i].is(k) {
+			return false
+		}
+	}
+	st.at += len(keywords)
+	return true
+}
+
+// name reads a name that may be qualified by its schema
+func (st *pgStatement) name() (string, string) {
+	name := st.next().ident()
+	if st.peek().text != "." {
+		return "", name
+	}
+	st.next()
+	return name, st.next().ident()
+}
+
+// group reads the tokens in parenthesis, without them
+func (st *pgStatement) group() []pgToken {
+	if st.peek().text != "(" {
+		return nil
+	}
+	start := st.at + 1
+	depth := 0
+	for !st.done() {
+		switch st.next().text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return st.tokens[start : st.at-1]
+			}
+		}
+	}
+	return st.tokens[start:]
+}
+
+// pgSplit splits tokens at a symbol that is not in parenthesis
+func pgSplit(tokens []pgToken, sep string) [][]pgToken {
+	var (
+		parts = make([][]pgToken, 0, 8)
+		depth = 0
+		start = 0
+	)
+	for i, tok := range tokens {
+		if tok.kind != pgTokenSymbol {
+			continue
+		}
+		switch tok.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(tokens) {
+		parts = append(parts, tokens[start:])
+	}
+	return parts
+}
+
+// pgNames is the names listed in a group, '(a, b)'
+func pgNames(tokens []pgToken) []string {
+	names := make([]string, 0, len(tokens))
+	for _, part := range pgSplit(tokens, ",") {
+		if len(part) == 0 {
+			continue
+		}
+		names = append(names, part[0].ident())
+	}
+	return names
+}
+
+// pgType is the declared type of a column
+type pgType struct {
+	schema string
+	// name is the words of the type, 'character varying'
+	name  string
+	args  []string
+	array bool
+}
+
+type pgColumn struct {
+	name    string
+	typ     pgType
+	notNull bool
+	def     []pgToken
+	// identity is true if the database numbers the column
+	identity bool
+	line     int
+}
+
+type pgUnique struct {
+	name string
+	cols []string
+}
+
+type pgForeign struct {
+	cols     []string
+	schema   string
+	table    string
+	refCols  []string
+	onDelete model.RefAction
+	onUpdate model.RefAction
+	line     int
+}
+
+// actions reads what a foreign key does as what it references changes,
+// such as 'ON DELETE SET NULL'. An action may list the columns it sets,
+// which are all of them here.
+func (fk *pgForeign) actions(el *pgStatement) {
+	if el.keyword("MATCH") {
+		el.next()
+	}
+	for el.keyword("ON") {
+		action := &fk.onUpdate
+		if el.keyword("DELETE") {
+			action = &fk.onDelete
+		} else {
+			el.next()
+		}
+		switch {
+		case el.keyword("CASCADE"):
+			*action = model.RefActionCascade
+		case el.keyword("RESTRICT"):
+			*action = model.RefActionRestrict
+		case el.keyword("SET", "NULL"):
+			*action = model.RefActionSetNull
+			el.group()
+		case el.keyword("SET", "DEFAULT"):
+			*action = model.RefActionSetDefault
+			el.group()
+		default:
+			el.keyword("NO")
+			el.next()
+		}
+	}
+}
+
+type pgTable struct {
+	schema  string
+	name    string
+	columns []*pgColumn
+	primary []string
+	uniques []pgUnique
+	foreign []pgForeign
+	line    int
+}
+
+func (tbl *pgTable) column(name string) *pgColumn {
+	for _, col := range tbl.columns {
+		if col.name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+// pgImport is what is learned of a database from its ddl
+type pgImport struct {
+	lines       []string
+	schemas     []string
+	enums       map[string][]string
+	tables      []*pgTable
+	diagnostics []Diagnostic
+}
+
+func (imp *pgImport) diagnose(line int, sev Severity, code Code, msg string) {
+	raw := ""
+	if line > 0 && line <= len(imp.lines) {
+		raw = imp.lines[line-1]
+	}
+	sl := newSourceLine(line, raw)
+	imp.diagnostics = append(imp.diagnostics, sl.diagnostic(sl.whole(), sev, code, msg))
+}
+
+func (imp *pgImport) addSchema(name string) {
+	if !slices.Contains(imp.schemas, name) {
+		imp.schemas = append(imp.schemas, name)
+	}
+}
+
+func (imp *pgImport) table(schema, name string) *pgTable {
+	for _, tbl := range imp.tables {
+		if tbl.schema == schema && tbl.name == name {
+			return tbl
+		}
+	}
+	return nil
+}
+
+// resolve finds what an unqualified name refers to, first in
+// the schema it is used in, then public, then anywhere
+func (imp *pgImport) resolve(schema, usedIn, name string) *pgTable {
+	if len(schema) > 0 {
+		return imp.table(schema, name)
+	}
+	for _, sch := range []string{usedIn, _pgPublic} {
+		if tbl := imp.table(sch, name); tbl != nil {
+			return tbl
+		}
+	}
+	for _, tbl := range imp.tables {
+		if tbl.name == name {
+			return tbl
+		}
+	}
+	return nil
+}
+
+func (imp *pgImport) enum(typ pgType, usedIn string) ([]string, bool) {
+	if len(typ.schema) > 0 {
+		values, ok := imp.enums[typ.schema+"."+typ.name]
+		return values, ok
+	}
+	for _, sch := range []string{usedIn, _pgPublic} {
+		if values, ok := imp.enums[sch+"."+typ.name]; ok {
+			return values, ok
+		}
+	}
+	return nil, false
+}
+
+func (imp *pgImport) statement(st *pgStatement) {
+	line := st.peek().line
+
+	switch {
+	case st.keyword("CREATE", "SCHEMA"):
+		st.keyword("IF", "NOT", "EXISTS")
+		imp.addSchema(st.next().ident())
+	case st.keyword("CREATE", "TYPE"):
+		schema, name := st.name()
+		if !st.keyword("AS", "ENUM") {
+			imp.diagnose(line, SeverityWarning, CodeUnknownLine, fmt.Sprintf("%s: type %s", ErrUnknownStatement, name))
+			return
+		}
+		if len(schema) == 0 {
+			schema = _pgPublic
+		}
+		values := make([]string, 0, 4)
+		for _, part := range pgSplit(st.group(), ",") {
+			if len(part) > 0 {
+				values = append(values, part[0].text)
+			}
+		}
+		imp.enums[schema+"."+name] = values
+	case st.keyword("CREATE", "UNIQUE", "INDEX"):
+		imp.uniqueIndex(st)
+	case st.keyword("ALTER", "TABLE"):
+		imp.alterTable(st)
+	case st.keyword("CREATE"):
+		for st.peek().is("GLOBAL", "LOCAL", "TEMP", "TEMPORARY", "UNLOGGED") {
+			st.next()
+		}
+		switch {
+		case st.keyword("TABLE"):
+			imp.createTable(st, line)
+		case st.peek().is("SEQUENCE", "INDEX", "EXTENSION"):
+			// these hold nothing of the model
+		default:
+			imp.diagnose(line, SeverityWarning, CodeUnknownLine, ErrUnknownStatement.Error())
+		}
+	}
+}
+
+func (imp *pgImport) createTable(st *pgStatement, line int) {
+	st.keyword("IF", "NOT", "EXISTS")
+
+	schema, name := st.name()
+	if len(schema) == 0 {
+		schema = _pgPublic
+	}
+	if len(name) == 0 {
+		imp.diagnose(line, SeverityError, CodeEntityName, ErrIdentifierRequired.Error())
+		return
+	}
+
+	tbl := &pgTable{
+		schema:  schema,
+		name:    name,
+		columns: make([]*pgColumn, 0, 10),
+		line:    line,
+	}
+
+	for _, part := range pgSplit(st.group(), ",") {
+		if len(part) == 0 {
+			continue
+		}
+		el := &pgStatement{tokens: part}
+		if el.peek().is("CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN", "CHECK", "EXCLUDE") {
+			imp.constraint(tbl, el)
+			continue
+		}
+		imp.column(tbl, el)
+	}
+
+	imp.addSchema(schema)
+	imp.tables = append(imp.tables, tbl)
+}
+
+// _pgColumnConstraint are the words that end the type of a column
+var _pgColumnConstraint = []string{
+	"CONSTRAINT", "NOT", "NULL", "DEFAULT", "PRIMARY", "UNIQUE",
+	"REFERENCES", "CHECK", "GENERATED", "COLLATE",
+}
+
+func (imp *pgImport) column(tbl *pgTable, el *pgStatement) {
+	col := &pgColumn{
+		line: el.peek().line,
+		name: el.next().ident(),
+	}
+
+	typ := pgType{}
+	words := make([]string, 0, 3)
+	for !el.done() && !el.peek().is(_pgColumnConstraint...) {
+		tok := el.peek()
+		switch {
+		case tok.text == "(":
+			for _, part := range pgSplit(el.group(), ",") {
+				if len(part) > 0 {
+					typ.args = append(typ.args, part[0].text)
+				}
+			}
+			continue
+		case tok.text == "[", tok.is("ARRAY"):
+			typ.array = true
+		case tok.text == ".":
+			typ.schema = strings.Join(words, " ")
+			words = words[:0]
+		case tok.kind == pgTokenWord || tok.kind == pgTokenIdent:
+			words = append(words, tok.ident())
+		}
+		el.next()
+	}
+	typ.name = strings.Join(words, " ")
+	col.typ = typ
+
+	for !el.done() {
+		switch {
+		case el.keyword("NOT", "NULL"):
+			col.notNull = true
+		case el.keyword("PRIMARY", "KEY"):
+			tbl.primary = []string{col.name}
+		case el.keyword("UNIQUE"):
+			tbl.uniques = append(tbl.uniques, pgUnique{cols: []string{col.name}})
+		case el.keyword("REFERENCES"):
+			fk := pgForeign{cols: []string{col.name}, line: col.line}
+			fk.schema, fk.table = el.name()
+			fk.refCols = pgNames(el.group())
+			// an action may set a default, which is not the default of the column
+			fk.actions(el)
+			tbl.foreign = append(tbl.foreign, fk)
+		case el.keyword("DEFAULT"):
+			start := el.at
+			el.next()
+			for !el.done() && !el.peek().is(_pgColumnConstraint...) {
+				if el.peek().text == "(" {
+					el.group()
+					continue
+				}
+				el.next()
+			}
+			col.def = el.tokens[start:el.at]
+		case el.keyword("GENERATED"):
+			for !el.done() && !el.peek().is("AS") {
+				el.next()
+			}
+			el.next()
+			col.identity = el.keyword("IDENTITY")
+			el.group()
+		case el.peek().text == "(":
+			el.group()
+		default:
+			el.next()
+		}
+	}
+
+	tbl.columns = append(tbl.columns, col)
+}
+
+// constraint reads a table constraint
+func (imp *pgImport) constraint(tbl *pgTable, el *pgStatement) {
+	line := el.peek().line
+	name := ""
+	if el.keyword("CONSTRAINT") {
+		name = el.next().ident()
+	}
+
+	switch {
+	case el.keyword("PRIMARY", "KEY"):
+		tbl.primary = pgNames(el.group())
+	case el.keyword("UNIQUE"):
+		el.keyword("NULLS", "NOT", "DISTINCT")
+		tbl.uniques = append(tbl.uniques, pgUnique{name: name, cols: pgNames(el.group())})
+	case el.keyword("FOREIGN", "KEY"):
+		fk := pgForeign{cols: pgNames(el.group()), line: line}
+		if el.keyword("REFERENCES") {
+			fk.schema, fk.table = el.name()
+			fk.refCols = pgNames(el.group())
+			fk.actions(el)
+			tbl.foreign = append(tbl.foreign, fk)
+		}
+	}
+}
+
+// uniqueIndex reads a unique index on plain columns as a unique constraint
+func (imp *pgImport) uniqueIndex(st *pgStatement) {
+	st.keyword("CONCURRENTLY")
+	st.keyword("IF", "NOT", "EXISTS")
+	name := ""
+	if !st.peek().is("ON") {
+		_, name = st.name()
+	}
+	if !st.keyword("ON") {
+		return
+	}
+	st.keyword("ONLY")
+	schema, table := st.name()
+	tbl := imp.resolve(schema, _pgPublic, table)
+	if tbl == nil {
+		return
+	}
+	if st.keyword("USING") {
+		st.next()
+	}
+
+	cols := make([]string, 0, 2)
+	for _, part := range pgSplit(st.group(), ",") {
+		// an expression is not something an attribute can be unique on
+		if len(part) == 0 || part[0].kind == pgTokenSymbol || tbl.column(part[0].ident()) == nil {
+			return
+		}
+		cols = append(cols, part[0].ident())
+	}
+	tbl.uniques = append(tbl.uniques, pgUnique{name: name, cols: cols})
+}
+
+// alterTable reads the constraints, defaults, and identities a
+// dump adds to a table after it is created
+func (imp *pgImport) alterTable(st *pgStatement) {
+	st.keyword("IF", "EXISTS")
+	st.keyword("ONLY")
+	schema, name := st.name()
+	tbl := imp.resolve(schema, _pgPublic, name)
+	if tbl == nil {
+		return
+	}
+
+	for _, part := range pgSplit(st.tokens[st.at:], ",") {
+		el := &pgStatement{tokens: part}
+		switch {
+		case el.keyword("ADD"):
+			if el.peek().is("CONSTRAINT", "PRIMARY", "UNIQUE", "FOREIGN") {
+				imp.constraint(tbl, el)
+			}
+		case el.keyword("ALTER"):
+			el.keyword("COLUMN")
+			col := tbl.column(el.next().ident())
+			if col == nil {
+				continue
+			}
+			switch {
+			case el.keyword("SET", "DEFAULT"):
+				col.def = el.tokens[el.at:]
+			case el.keyword("SET", "NOT", "NULL"):
+				col.notNull = true
+			case el.keyword("ADD", "GENERATED"):
+				col.identity = true
+			}
+		}
+	}
+}
+
+// pgDefault is the value of a default expression as an option,
+// and if the value is numbered by a sequence
+func pgDefault(tokens []pgToken) (string, bool, bool) {
+	// casts and parenthesis do not change the value
+	value := make([]pgToken, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if tok.text == "::" && tok.kind == pgTokenSymbol {
+			for i+1 < len(tokens) && (tokens[i+1].kind != pgTokenSymbol || tokens[i+1].text == "." || tokens[i+1].text == "[" || tokens[i+1].text == "]") {
+				i++
+			}
+			continue
+		}
+		if tok.kind == pgTokenSymbol && (tok.text == "(" || tok.text == ")") {
+			continue
+		}
+		value = append(value, tok)
+	}
+
+	if len(value) == 0 {
+		return "", false, false
+	}
+
+	first := value[0]
+	switch {
+	case first.is("nextval"):
+		return "", true, true
+	case first.is("now", "current_timestamp", "current_date", "current_time", "localtimestamp", "localtime", "transaction_timestamp", "statement_timestamp"):
+		return "now", false, true
+	case first.is("gen_random_uuid", "uuid_generate_v4"):
+		return "random", false, true
+	case len(value) > 1:
+		if first.text == "-" && value[1].kind == pgTokenWord {
+			return "-" + value[1].text, false, true
+		}
+		return "", false, false
+	case first.is("NULL"):
+		return "", false, true
+	case first.is("true", "false"):
+		return strings.ToLower(first.text), false, true
+	case first.kind == pgTokenString:
+		// bytea is written as hex, '\xdeadbeef'
+		return strings.TrimPrefix(first.text, `\x`), false, true
+	case first.kind == pgTokenWord:
+		return first.text, false, true
+	}
+	return "", false, false
+}
+
+// pgKind is the kind of a postgres type, and the max length it declares
+func pgKind(typ pgType) (model.AttrKind, string, bool) {
+	arg := ""
+	if len(typ.args) > 0 {
+		arg = typ.args[0]
+	}
+
+	switch typ.name {
+	case "smallint", "int2", "integer", "int", "int4", "bigint", "int8",
+		"smallserial", "serial2", "serial", "serial4", "bigserial", "serial8":
+		return model.AttrKindInt, "", true
+	case "character varying", "varchar":
+		if len(arg) == 0 {
+			return model.AttrKindText, "", true
+		}
+		return model.AttrKindString, arg, true
+	case "character", "char", "bpchar":
+		if len(arg) == 0 || arg == "1" {
+			return model.AttrKindChar, "", true
+		}
+		return model.AttrKindString, arg, true
+	case "text", "citext":
+		return model.AttrKindText, "", true
+	case "boolean", "bool":
+		return model.AttrKindBoolean, "", true
+	case "date":
+		return model.AttrKindDate, "", true
+	case "time", "time without time zone", "time with time zone", "timetz":
+		return model.AttrKindTime, "", true
+	case "timestamp", "timestamp without time zone", "timestamp with time zone", "timestamptz":
+		return model.AttrKindTimestamp, "", true
+	case "double precision", "float8", "float":
+		return model.AttrKindFloat, "", true
+	case "real", "float4":
+		return model.AttrKindReal, "", true
+	case "numeric", "decimal":
+		return model.AttrKindDecimal, "", true
+	case "money":
+		return model.AttrKindMoney, "", true
+	case "uuid":
+		return model.AttrKindUUID, "", true
+	case "bytea":
+		return model.AttrKindBytes, "", true
+	case "json", "jsonb":
+		return model.AttrKindJSON, "", true
+	case "bit", "bit varying", "varbit":
+		if len(arg) == 0 {
+			arg = "1"
+		}
+		return model.AttrKindBit, arg, true
+	}
+	return model.AttrKindNone, "", false
+}
+
+// isPgSerial is true of a type that is numbered by a sequence
+func isPgSerial(typ pgType) bool {
+	switch typ.name {
+	case "smallserial", "serial2", "serial", "serial4", "bigserial", "serial8":
+		return true
+	}
+	return false
+}
+
+// reference is the foreign keys of a table to an entity that can be
+// written as a reference, and the alias its columns are named by
+type pgReference struct {
+	fk    pgForeign
+	to    *pgTable
+	alias string
+}
+
+// references determines which foreign keys can be written as a reference.
+// A reference is to the whole primary key of an entity, with each
+// column named by the entity or an alias, then the key it holds.
+// A composite key may be given as a foreign key per column.
+func (imp *pgImport) references(tbl *pgTable) []pgReference {
+	var (
+		refs   = make([]pgReference, 0, len(tbl.foreign))
+		groups = make(map[*pgTable]*pgForeign)
+		order  = make([]*pgTable, 0, len(tbl.foreign))
+	)
+
+	for _, fk := range tbl.foreign {
+		to := imp.resolve(fk.schema, tbl.schema, fk.table)
+		if to == nil {
+			imp.diagnose(fk.line, SeverityWarning, CodeReference, fmt.Sprintf("%s: %s", ErrForeignKeyIgnored, fk.table))
+			continue
+		}
+		if len(fk.refCols) == 0 {
+			fk.refCols = to.primary
+		}
+		if g, ok := groups[to]; ok {
+			g.cols = append(slices.Clone(g.cols), fk.cols...)
+			g.refCols = append(slices.Clone(g.refCols), fk.refCols...)
+			continue
+		}
+		groups[to] = &fk
+		order = append(order, to)
+	}
+
+	for _, to := range order {
+		fk := *groups[to]
+
+		ok := len(fk.refCols) == len(to.primary) && len(fk.cols) == len(fk.refCols)
+		for _, col := range fk.refCols {
+			if !slices.Contains(to.primary, col) {
+				ok = false
+			}
+		}
+		// a key that is itself a reference is not flattened
+		for _, other := range to.foreign {
+			for _, col := range other.cols {
+				if slices.Contains(to.primary, col) {
+					ok = false
+				}
+			}
+		}
+
+		alias := ""
+		for i := 0; ok && i < len(fk.cols); i++ {
+			prefix, found := strings.CutSuffix(fk.cols[i], "_"+fk.refCols[i])
+			if !found || len(prefix) == 0 || (i > 0 && prefix != alias) {
+				ok = false
+			}
+			alias = prefix
+		}
+
+		if !ok {
+			imp.diagnose(fk.line, SeverityWarning, CodeReference, fmt.Sprintf("%s: %s", ErrForeignKeyIgnored, fk.table))
+			continue
+		}
+		if alias == to.name {
+			alias = ""
+		}
+		refs = append(refs, pgReference{fk: fk, to: to, alias: alias})
+	}
+
+	return refs
+}
+
+// uniqueLabels are the labels of the unique constraints columns are in
+func uniqueLabels(tbl *pgTable, cols []string) []string {
+	labels := make([]string, 0, 2)
+	for _, u := range tbl.uniques {
+		in := false
+		for _, col := range cols {
+			if slices.Contains(u.cols, col) {
+				in = true
+			}
+		}
+		if !in {
+			continue
+		}
+		label := internal.Normalize(strings.Join(u.cols, "_"))
+		if len(u.cols) > 1 && len(u.name) > 0 {
+			label = internal.Normalize(u.name)
+		}
+		if !slices.Contains(labels, label) {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+func (imp *pgImport) attribute(tbl *pgTable, ent *model.Entity, col *pgColumn) *model.AttributeRaw {
+	attr := model.NewAttribute(ent)
+	attr.Name = internal.Normalize(col.name)
+	attr.Array = col.typ.array
+	attr.Primary = slices.Contains(tbl.primary, col.name)
+	attr.Unique = uniqueLabels(tbl, []string{col.name})
+
+	kind, max, ok := pgKind(col.typ)
+	if !ok {
+		values, isEnum := imp.enum(col.typ, tbl.schema)
+		switch {
+		case isEnum:
+			kind = model.AttrKindEnum
+			attr.EnumValues = values
+		default:
+			kind = model.AttrKindText
+			imp.diagnose(col.line, SeverityWarning, CodeKind, fmt.Sprintf("%s: %s imported as text", ErrKindInvalid, col.typ.name))
+		}
+	}
+	attr.Kind = kind
+	if len(max) > 0 {
+		attr.Max = sql.NullString{String: max, Valid: true}
+	}
+
+	value, sequence, ok := pgDefault(col.def)
+	if !ok && len(col.def) > 0 {
+		imp.diagnose(col.line, SeverityWarning, CodeDefault, fmt.Sprintf("%s: default", model.ErrOptionIgnored))
+	}
+	sequence = sequence || col.identity || isPgSerial(col.typ)
+
+	// only a key has its value generated, elsewhere it is the base kind
+	switch {
+	case attr.Primary && sequence && kind == model.AttrKindInt && !attr.Array:
+		attr.Kind = model.AttrKindSerial
+	case attr.Primary && value == "random" && kind == model.AttrKindUUID && !attr.Array:
+		attr.Kind = model.AttrKindGeneratedUUID
+	default:
+		attr.DefaultValue = value
+	}
+
+	if col.notNull || attr.Primary {
+		attr.Required = sql.NullBool{Bool: true, Valid: true}
+	}
+
+	return attr
+}
+
+// build makes the model of what was imported
+func (imp *pgImport) build() []*model.Schema {
+	var (
+		schemas  = make([]*model.Schema, 0, len(imp.schemas))
+		bySchema = make(map[string]*model.Schema, len(imp.schemas))
+		byTable  = make(map[*pgTable]*model.Entity, len(imp.tables))
+		lines    = make(map[*model.AttributeRaw]int)
+	)
+
+	for _, name := range imp.schemas {
+		sch := model.NewSchema()
+		sch.Name = internal.Normalize(name)
+		bySchema[name] = sch
+		schemas = append(schemas, sch)
+	}
+
+	for _, tbl := range imp.tables {
+		sch := bySchema[tbl.schema]
+		ent := model.NewEntity(sch)
+		ent.Name = internal.Normalize(tbl.name)
+		byTable[tbl] = ent
+		sch.Entities = append(sch.Entities, ent)
+	}
+
+	for _, tbl := range imp.tables {
+		var (
+			ent    = byTable[tbl]
+			refs   = imp.references(tbl)
+			placed = make(map[int]bool, len(refs))
+		)
+
+		for _, col := range tbl.columns {
+			at := slices.IndexFunc(refs, func(ref pgReference) bool {
+				return slices.Contains(ref.fk.cols, col.name)
+			})
+			if at < 0 {
+				attr := imp.attribute(tbl, ent, col)
+				lines[attr] = col.line
+				ent.RawAttributes = append(ent.RawAttributes, attr)
+				continue
+			}
+			if placed[at] {
+				continue
+			}
+			placed[at] = true
+
+			ref := refs[at]
+			attr := model.NewAttribute(ent)
+			attr.Kind = model.AttrKindReference
+			attr.ReferenceTo = byTable[ref.to]
+			attr.Name = attr.ReferenceTo.Name
+			attr.Alias = internal.Normalize(ref.alias)
+			attr.Unique = uniqueLabels(tbl, ref.fk.cols)
+			attr.OnDelete = ref.fk.onDelete
+			attr.OnUpdate = ref.fk.onUpdate
+
+			primary, required := true, true
+			for _, name := range ref.fk.cols {
+				c := tbl.column(name)
+				if !slices.Contains(tbl.primary, name) {
+					primary = false
+				}
+				if c == nil || !c.notNull {
+					required = false
+				}
+			}
+			attr.Primary = primary
+			if required || primary {
+				attr.Required = sql.NullBool{Bool: true, Valid: true}
+			}
+			lines[attr] = ref.fk.line
+			ent.RawAttributes = append(ent.RawAttributes, attr)
+		}
+
+		for _, attr := range ent.RawAttributes {
+			line := lines[attr]
+			attr.SanitizeEnumValues()
+			attr.EnsureValidRange()
+			attr.MaybeRequireValidation()
+			attr.EnsureValidPrimary()
+			attr.EnsureValidArray()
+			attr.EnsureValidAction()
+			attr.SanitizeDefaultValue()
+			attr.SanativeGeneratedKind()
+
+			for _, err := range attr.Err {
+				imp.diagnose(line, SeverityError, CodeAttribute, fmt.Sprintf("%s: %s", attr.Name, err))
+			}
+			for _, err := range attr.Warn {
+				imp.diagnose(line, SeverityWarning, CodeAttribute, fmt.Sprintf("%s: %s", attr.Name, err))
+			}
+		}
+	}
+
+	sortByReference(schemas)
+	return schemas
+}
+
+// Postgres takes in postgres ddl, like a schema only dump, and provides
+// the schemas it describes. A statement that cannot be described, such
+// as a view, is skipped with a diagnostic.
+func Postgres(s string) *Result {
+	imp := pgImport{
+		lines:       strings.Split(s, "\n"),
+		schemas:     make([]string, 0, 3),
+		enums:       make(map[string][]string),
+		tables:      make([]*pgTable, 0, 10),
+		diagnostics: make([]Diagnostic, 0),
+	}
+
+	for _, tokens := range pgSplit(pgTokenize(s), ";") {
+		if len(tokens) == 0 {
+			continue
+		}
+		imp.statement(&pgStatement{tokens: tokens})
+	}
+
+	schemas := imp.build()
+
+	slices.SortStableFunc(imp.diagnostics, func(a, b Diagnostic) int {
+		return a.Line - b.Line
+	})
+
+	return &Result{
+		Schemas:     schemas,
+		Diagnostics: imp.diagnostics,
+	}
+}
@@ -0,0 +1,339 @@
+package strparse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Isaac799/devtoolbox/pkg/model"
+)
+
+// testMockPostgresKitchen is as the postgres generator writes the kitchen
+const testMockPostgresKitchen = `CREATE SCHEMA kitchen;
+CREATE TYPE kitchen.ingredient_storage AS ENUM ('dry', 'cold', 'frozen');
+
+CREATE TABLE kitchen.supplier (
+    k_1 SERIAL,
+    k_2 SERIAL,
+    name VARCHAR(30) NOT NULL,
+    PRIMARY KEY(k_1, k_2)
+);
+
+CREATE TABLE kitchen.ingredient (
+    id SERIAL,
+    name VARCHAR(30) DEFAULT 'foo' NOT NULL,
+    eol DATE NOT NULL,
+    storage kitchen.ingredient_storage NOT NULL,
+    supplier_k_1 INT NOT NULL,
+    supplier_k_2 INT NOT NULL,
+    UNIQUE(name),
+    PRIMARY KEY(id),
+    FOREIGN KEY(supplier_k_1) REFERENCES supplier(k_1),
+    FOREIGN KEY(supplier_k_2) REFERENCES supplier(k_2)
+);
+
+CREATE TABLE kitchen.food (
+    id SERIAL,
+    name VARCHAR(30) NOT NULL,
+    PRIMARY KEY(id)
+);
+
+CREATE TABLE kitchen.recipe (
+    food_id INT NOT NULL,
+    ingredient_id INT NOT NULL,
+    amount INT NOT NULL,
+    PRIMARY KEY(food_id, ingredient_id),
+    FOREIGN KEY(food_id) REFERENCES food(id),
+    FOREIGN KEY(ingredient_id) REFERENCES ingredient(id)
+);`
+
+// testMockPostgresDump is as pg_dump --schema-only writes a database
+const testMockPostgresDump = `--
+-- PostgreSQL database dump
+--
+
+SET statement_timeout = 0;
+SELECT pg_catalog.set_config('search_path', '', false);
+
+CREATE SCHEMA shop;
+
+CREATE TYPE public.mood AS ENUM (
+    'happy',
+    'sad'
+);
+
+CREATE TABLE public.customer (
+    id integer NOT NULL,
+    "firstName" character varying(40) NOT NULL,
+    email text,
+    mood public.mood DEFAULT 'happy'::public.mood,
+    joined timestamp with time zone DEFAULT now() NOT NULL,
+    tags character varying(20)[]
+);
+
+ALTER TABLE public.customer OWNER TO postgres;
+
+CREATE SEQUENCE public.customer_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1;
+
+ALTER SEQUENCE public.customer_id_seq OWNED BY public.customer.id;
+
+CREATE TABLE shop.item (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    sku uuid DEFAULT gen_random_uuid(),
+    price numeric(10,2) DEFAULT 1.5 NOT NULL,
+    settings jsonb DEFAULT '{}'::jsonb,
+    seen date DEFAULT ('now'::text)::date,
+    owner_id integer,
+    logo bytea
+);
+
+CREATE TABLE shop.tally (
+    n bigint GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    label character(1),
+    fee money DEFAULT '-5' NOT NULL,
+    at point
+);
+
+CREATE VIEW shop.cheap AS
+ SELECT id FROM shop.item WHERE price < 2;
+
+CREATE FUNCTION shop.touch() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    RETURN NEW;
+END;
+$$;
+
+ALTER TABLE ONLY public.customer ALTER COLUMN id SET DEFAULT nextval('public.customer_id_seq'::regclass);
+
+ALTER TABLE ONLY public.customer
+    ADD CONSTRAINT customer_pkey PRIMARY KEY (id);
+
+ALTER TABLE ONLY shop.item
+    ADD CONSTRAINT item_pkey PRIMARY KEY (id);
+
+CREATE UNIQUE INDEX customer_email_key ON public.customer USING btree (email);
+
+CREATE INDEX item_price_idx ON shop.item USING btree (price);
+
+ALTER TABLE ONLY shop.item
+    ADD CONSTRAINT item_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES public.customer(id) ON DELETE SET NULL;
+`
+
+func testEntity(t *testing.T, schemas []*model.Schema, schema, name string) *model.Entity {
+	t.Helper()
+	for _, sch := range schemas {
+		if sch.Name != schema {
+			continue
+		}
+		for _, ent := range sch.Entities {
+			if ent.Name == name {
+				return ent
+			}
+		}
+	}
+	t.Fatalf("expected entity %s.%s", schema, name)
+	return nil
+}
+
+func testAttribute(t *testing.T, ent *model.Entity, name string) *model.AttributeRaw {
+	t.Helper()
+	for _, attr := range ent.RawAttributes {
+		if attr.Name == name {
+			return attr
+		}
+	}
+	t.Fatalf("expected attribute %s of %s", name, ent.Name)
+	return nil
+}
+
+// testRoundTrip writes the schemas as text, as the ui does, and parses it back
+// as deep as they were parsed
+func testRoundTrip(t *testing.T, schemas []*model.Schema) *Result {
+	t.Helper()
+	opts := Options{}
+	for _, sch := range schemas {
+		for _, ent := range sch.Entities {
+			opts.Depth = max(opts.Depth, ent.Depth())
+		}
+	}
+	s := Format(schemas)
+	result := ParseWith(s, opts)
+	for _, d := range result.Diagnostics {
+		t.Error(d.String())
+	}
+	if t.Failed() {
+		t.Fatal(s)
+	}
+	return result
+}
+
+func TestPostgres(t *testing.T) {
+	result := Postgres(testMockPostgresKitchen)
+	for _, d := range result.Diagnostics {
+		t.Error(d.String())
+	}
+
+	schemas := result.Schemas
+	testNoErr(t, schemas)
+
+	supplier := testEntity(t, schemas, "kitchen", "supplier")
+	if k := testAttribute(t, supplier, "k_1"); k.Kind != model.AttrKindSerial {
+		t.Fatal("expected serial key: ", k.Kind)
+	}
+
+	ingredient := testEntity(t, schemas, "kitchen", "ingredient")
+	name := testAttribute(t, ingredient, "name")
+	if name.Kind != model.AttrKindString || name.Max.String != "30" || name.DefaultValue != "foo" || !name.Required.Bool {
+		t.Fatal("expected required string to 30 with default: ", name.String())
+	}
+	if len(name.Unique) != 1 || name.Unique[0] != "name" {
+		t.Fatal("expected unique on its own: ", name.Unique)
+	}
+	if storage := testAttribute(t, ingredient, "storage"); storage.Kind != model.AttrKindEnum || storage.EnumLabels() != "dry, cold, frozen" {
+		t.Fatal("expected enum: ", storage.String())
+	}
+
+	// a foreign key per column of a composite key is one reference
+	ref := testAttribute(t, ingredient, "supplier")
+	if ref.ReferenceTo != supplier || !ref.Required.Bool {
+		t.Fatal("expected required reference to supplier: ", ref.String())
+	}
+	if len(ingredient.RawAttributes) != 5 {
+		t.Fatal("expected supplier columns to be the reference: ", len(ingredient.RawAttributes))
+	}
+
+	recipe := testEntity(t, schemas, "kitchen", "recipe")
+	for _, s := range []string{"food", "ingredient"} {
+		if attr := testAttribute(t, recipe, s); !attr.Primary || attr.Kind != model.AttrKindReference {
+			t.Fatal("expected primary reference: ", attr.String())
+		}
+	}
+
+	// what is referenced is written before it is referenced
+	names := make([]string, 0, 4)
+	for _, ent := range schemas[0].Entities {
+		names = append(names, ent.Name)
+	}
+	if strings.Join(names, ",") != "supplier,ingredient,food,recipe" {
+		t.Fatal("unexpected order: ", names)
+	}
+
+	testRoundTrip(t, schemas)
+}
+
+func TestPostgresDump(t *testing.T) {
+	result := Postgres(testMockPostgresDump)
+	schemas := result.Schemas
+	testNoErr(t, schemas)
+
+	if len(schemas) != 2 || schemas[0].Name != "public" || schemas[1].Name != "shop" {
+		t.Fatal("expected public then shop, as shop references public")
+	}
+
+	customer := testEntity(t, schemas, "public", "customer")
+	if id := testAttribute(t, customer, "id"); id.Kind != model.AttrKindSerial {
+		t.Fatal("expected a key from a sequence to be serial: ", id.String())
+	}
+	if first := testAttribute(t, customer, "first_name"); first.Max.String != "40" {
+		t.Fatal("expected quoted name to be normalized: ", first.String())
+	}
+	if email := testAttribute(t, customer, "email"); email.Kind != model.AttrKindText || len(email.Unique) != 1 {
+		t.Fatal("expected unique index as unique: ", email.String())
+	}
+	if mood := testAttribute(t, customer, "mood"); mood.Kind != model.AttrKindEnum || mood.DefaultValue != "happy" {
+		t.Fatal("expected enum with default: ", mood.String())
+	}
+	if joined := testAttribute(t, customer, "joined"); joined.Kind != model.AttrKindTimestamp || joined.DefaultValue != "now" {
+		t.Fatal("expected timestamp default now: ", joined.String())
+	}
+	if tags := testAttribute(t, customer, "tags"); !tags.Array || tags.Kind != model.AttrKindString {
+		t.Fatal("expected array: ", tags.String())
+	}
+
+	item := testEntity(t, schemas, "shop", "item")
+	for _, tc := range []struct {
+		name  string
+		kind  model.AttrKind
+		value string
+	}{
+		{"id", model.AttrKindGeneratedUUID, ""},
+		{"sku", model.AttrKindUUID, "random"},
+		{"price", model.AttrKindDecimal, "1.5"},
+		{"settings", model.AttrKindJSON, "{}"},
+		{"seen", model.AttrKindDate, "now"},
+		{"logo", model.AttrKindBytes, ""},
+	} {
+		attr := testAttribute(t, item, tc.name)
+		if attr.Kind != tc.kind || attr.DefaultValue != tc.value {
+			t.Fatal("unexpected attribute: ", attr.String())
+		}
+	}
+
+	// a key not named for what it references is aliased
+	owner := testAttribute(t, item, "customer")
+	if owner.ReferenceTo != customer || owner.Alias != "owner" || owner.Required.Bool {
+		t.Fatal("expected optional reference as owner: ", owner.String())
+	}
+	if owner.OnDelete != model.RefActionSetNull {
+		t.Fatal("expected the action of the foreign key: ", owner.String())
+	}
+	if !strings.HasPrefix(owner.String(), "- @public.customer as owner") {
+		t.Fatal("expected reference across schemas to be qualified: ", owner.String())
+	}
+
+	tally := testEntity(t, schemas, "shop", "tally")
+	if n := testAttribute(t, tally, "n"); n.Kind != model.AttrKindSerial {
+		t.Fatal("expected identity to be serial: ", n.String())
+	}
+	if fee := testAttribute(t, tally, "fee"); fee.DefaultValue != "-5" {
+		t.Fatal("expected negative default: ", fee.String())
+	}
+	if label := testAttribute(t, tally, "label"); label.Kind != model.AttrKindChar {
+		t.Fatal("expected char: ", label.String())
+	}
+
+	var codes []Code
+	for _, d := range result.Diagnostics {
+		if d.IsErr() {
+			t.Fatal("expected only warnings: ", d.String())
+		}
+		codes = append(codes, d.Code)
+	}
+	// the point is text, the view and function are skipped
+	if strings.Join(toStrings(codes), ",") != "kind,unknown-line,unknown-line" {
+		t.Fatal("unexpected diagnostics: ", codes)
+	}
+
+	testRoundTrip(t, schemas)
+}
+
+func TestPostgresForeignKeyIgnored(t *testing.T) {
+	result := Postgres(`CREATE TABLE a (
+    id INT PRIMARY KEY,
+    code TEXT UNIQUE
+);
+CREATE TABLE b (
+    id INT PRIMARY KEY,
+    a_code TEXT REFERENCES a(code)
+);`)
+
+	b := testEntity(t, result.Schemas, "public", "b")
+	if code := testAttribute(t, b, "a_code"); code.Kind != model.AttrKindText {
+		t.Fatal("expected a key not to the primary to be a column: ", code.String())
+	}
+	if len(result.Diagnostics) != 1 || result.Diagnostics[0].Code != CodeReference || result.Diagnostics[0].Line != 7 {
+		t.Fatal("expected a reference warning: ", result.Diagnostics)
+	}
+}
+
+func toStrings[T ~string](arr []T) []string {
+	s := make([]string, 0, len(arr))
+	for _, v := range arr {
+		s = append(s, string(v))
+	}
+	return s
+}
@@ -20,6 +20,10 @@ func newSchFromLine(s string) (*model.Schema, error) {
 		Entities: make([]*model.Entity, 0, 5),
 	}
 
+	if len(sch.Name) == 0 {
+		return nil, ErrIdentifierRequired
+	}
+
 	for _, optRaw := range optsRaw {
 		opt := strings.TrimSpace(optRaw)
 
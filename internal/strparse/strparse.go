@@ -2,6 +2,7 @@ package strparse
 
 import (
 	"crypto/rand"
+	"slices"
 	"strings"
 
 	"github.com/Isaac799/devtoolbox/pkg/model"
@@ -21,35 +22,74 @@ func determineLineKind(s string) lineKind {
 
 // Raw takes in a string and provides the schemas
 func Raw(s string) []*model.Schema {
-	schemas := make([]*model.Schema, 0, 3)
+	return Parse(s).Schemas
+}
+
+// Options change how a string is parsed
+type Options struct {
+	// Depth is how many references deep keys are flattened,
+	// the model default if zero
+	Depth int
+}
+
+// Parse takes in a string and provides the schemas along with
+// diagnostics describing any line that could not be used as is
+func Parse(s string) *Result {
+	return ParseWith(s, Options{})
+}
+
+// ParseWith is Parse given options
+func ParseWith(s string, opts Options) *Result {
+	var (
+		schemas     = make([]*model.Schema, 0, 3)
+		diagnostics = make([]Diagnostic, 0)
+		attrLines   = make(map[*model.AttributeRaw]sourceLine)
+		attrOrder   = make([]*model.AttributeRaw, 0, 10)
+		refNames    = make(map[*model.AttributeRaw]string)
+	)
 
 	var prevSch *model.Schema
 	var prevEnt *model.Entity
 
+	number := 0
 	lines := strings.SplitSeq(s, "\n")
 	for lineRaw := range lines {
-		line := strings.TrimSpace(lineRaw)
+		number++
+		sl := newSourceLine(number, lineRaw)
+		line := sl.text()
 		lineKind := determineLineKind(line)
 
 		switch lineKind {
 		case lineKindSch:
 			sch, err := newSchFromLine(line)
 			if err != nil {
+				diagnostics = append(diagnostics, sl.diagnostic(sl.whole(), SeverityError, CodeSchemaName, err.Error()))
+				prevSch = nil
+				prevEnt = nil
 				continue
 			}
 			if len(sch.ID) == 0 {
 				sch.ID = rand.Text()
 			}
 			prevSch = sch
+			prevEnt = nil
 			schemas = append(schemas, sch)
 		case lineKindEnt:
 			if prevSch == nil {
+				diagnostics = append(diagnostics, sl.diagnostic(sl.whole(), SeverityError, CodeOrphanEntity, ErrOrphanEntity.Error()))
+				prevEnt = nil
 				continue
 			}
-			ent, err := newEntFromLine(line)
+			ent, warns, err := newEntFromLine(line)
 			if err != nil {
+				diagnostics = append(diagnostics, sl.diagnostic(sl.whole(), SeverityError, CodeEntityName, err.Error()))
+				prevEnt = nil
 				continue
 			}
+			_, _, optsSpan := attrSpans(line)
+			for _, warn := range warns {
+				diagnostics = append(diagnostics, sl.diagnostic(optsSpan, SeverityWarning, CodeOptionIgnored, warn.Error()))
+			}
 			if len(ent.ID) == 0 {
 				ent.ID = rand.Text()
 			}
@@ -58,6 +98,7 @@ func Raw(s string) []*model.Schema {
 			prevSch.Entities = append(prevSch.Entities, ent)
 		case lineKindAttr:
 			if prevEnt == nil {
+				diagnostics = append(diagnostics, sl.diagnostic(sl.whole(), SeverityError, CodeOrphanAttribute, ErrOrphanAttribute.Error()))
 				continue
 			}
 			attr := newAttributeFromLine(line)
@@ -66,28 +107,112 @@ func Raw(s string) []*model.Schema {
 			}
 			attr.Parent = prevEnt
 
+			// what is referenced may be declared later, so it is resolved
+			// once everything is parsed, by the name as written
 			if attr.Kind == model.AttrKindReference {
-				attr.EnsureValidReference(schemas)
+				refNames[attr] = attr.Name
 			}
 
 			attr.EnsureValidAlias(prevEnt)
 			attr.EnsureValidName(prevEnt)
+			attr.SanitizeEnumValues()
 			attr.EnsureValidRange()
 			attr.MaybeRequireValidation()
+			attr.EnsureValidPrimary()
+			attr.EnsureValidArray()
+			attr.EnsureValidAction()
 			attr.SanitizeDefaultValue()
-			attr.SanativeSerialKind()
+			attr.SanativeGeneratedKind()
 
 			prevEnt.RawAttributes = append(prevEnt.RawAttributes, attr)
+			attrLines[attr] = sl
+			attrOrder = append(attrOrder, attr)
 		default:
+			if len(line) > 0 {
+				diagnostics = append(diagnostics, sl.diagnostic(sl.whole(), SeverityWarning, CodeUnknownLine, ErrUnknownLine.Error()))
+			}
 			continue
 		}
 	}
 
+	// what an option of an entity adds follows what is written
+	for _, sch := range schemas {
+		for _, ent := range sch.Entities {
+			ent.EnsureOptionAttributes()
+		}
+	}
+
+	resolveReferences(schemas, attrOrder, refNames)
+	model.SetDepth(schemas, opts.Depth)
+
 	for _, s := range schemas {
 		for _, e := range s.Entities {
 			e.SetRelations(schemas)
 		}
 	}
 
-	return schemas
+	for _, attr := range attrOrder {
+		sl := attrLines[attr]
+		for _, err := range attr.Err {
+			diagnostics = append(diagnostics, attrErrDiagnostic(sl, err, SeverityError))
+		}
+		for _, err := range attr.Warn {
+			diagnostics = append(diagnostics, attrErrDiagnostic(sl, err, SeverityWarning))
+		}
+	}
+
+	slices.SortStableFunc(diagnostics, func(a, b Diagnostic) int {
+		return a.Line - b.Line
+	})
+
+	return &Result{
+		Schemas:     schemas,
+		Diagnostics: diagnostics,
+	}
+}
+
+// resolveReferences links each reference to what it names, regardless
+// of where either is declared. An entity keyed by a reference only has
+// a primary once that is linked, so it repeats while any more link.
+func resolveReferences(schemas []*model.Schema, attrs []*model.AttributeRaw, names map[*model.AttributeRaw]string) {
+	pending := make([]*model.AttributeRaw, 0, len(names))
+	for _, attr := range attrs {
+		if _, ok := names[attr]; ok {
+			pending = append(pending, attr)
+		}
+	}
+
+	for len(pending) > 0 {
+		clearCache(schemas)
+		unresolved := make([]*model.AttributeRaw, 0, len(pending))
+		for _, attr := range pending {
+			attr.ClearReferenceErr()
+
+			// a reused name is renamed, yet still references what it was
+			name := attr.Name
+			attr.Name = names[attr]
+			attr.EnsureValidReference(schemas)
+			if name != names[attr] {
+				attr.Name = name
+			}
+
+			if attr.ReferenceTo == nil {
+				unresolved = append(unresolved, attr)
+			}
+		}
+		if len(unresolved) == len(pending) {
+			break
+		}
+		pending = unresolved
+	}
+
+	clearCache(schemas)
+}
+
+func clearCache(schemas []*model.Schema) {
+	for _, sch := range schemas {
+		for _, ent := range sch.Entities {
+			ent.ClearCache()
+		}
+	}
 }
@@ -1,6 +1,9 @@
 package strparse
 
 import (
+	"errors"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/Isaac799/devtoolbox/internal"
@@ -45,8 +48,11 @@ const testMockSchemaRestaurant = `# Restaurant
 
 const testMockSchemas = testMockSchemaKitchen + "\n" + testMockSchemaRestaurant
 
+// testMockOptions are deep enough for an order of a recipe of food
+var testMockOptions = Options{Depth: 2}
+
 func TestParseRaw(t *testing.T) {
-	schemas := Raw(testMockSchemas)
+	schemas := ParseWith(testMockSchemas, testMockOptions).Schemas
 	testNoErr(t, schemas)
 }
 
@@ -72,7 +78,7 @@ func testNoErr(t *testing.T, schemas []*model.Schema) {
 }
 
 func TestRecursiveRead(t *testing.T) {
-	schemas := Raw(testMockSchemas)
+	schemas := ParseWith(testMockSchemas, testMockOptions).Schemas
 
 	attrs := schemas[1].Entities[1].Attributes()
 
@@ -119,3 +125,598 @@ func TestNormalize(t *testing.T) {
 		}
 	}
 }
+
+func TestParseDiagnostics(t *testing.T) {
+	const s = `## Orphan
+- id as ++
+
+# Kitchen
+
+## Food
+- id          as ++
+- name        as strang with required
+  - ok        as int
+- @missing
+not a line
+##  with timestamps
+#  with x`
+
+	res := Parse(s)
+
+	if !res.HasErr() {
+		t.Fatal("expected errors")
+	}
+
+	expect := []Diagnostic{
+		{Line: 1, Column: 1, ColumnEnd: 10, Severity: SeverityError, Code: CodeOrphanEntity},
+		{Line: 2, Column: 1, ColumnEnd: 11, Severity: SeverityError, Code: CodeOrphanAttribute},
+		{Line: 8, Column: 18, ColumnEnd: 24, Severity: SeverityError, Code: CodeKind},
+		{Line: 10, Column: 3, ColumnEnd: 11, Severity: SeverityError, Code: CodeReference},
+		{Line: 11, Column: 1, ColumnEnd: 11, Severity: SeverityWarning, Code: CodeUnknownLine},
+		{Line: 12, Column: 1, ColumnEnd: 20, Severity: SeverityError, Code: CodeEntityName},
+		{Line: 13, Column: 1, ColumnEnd: 10, Severity: SeverityError, Code: CodeSchemaName},
+	}
+
+	if len(res.Diagnostics) != len(expect) {
+		t.Fatal("unexpected diagnostic count: ", res.Diagnostics)
+	}
+
+	for i, d := range res.Diagnostics {
+		e := expect[i]
+		if d.Line != e.Line || d.Column != e.Column || d.ColumnEnd != e.ColumnEnd {
+			t.Fatal("bad position: ", d.String())
+		}
+		if d.Severity != e.Severity || d.Code != e.Code {
+			t.Fatal("bad classification: ", d.String())
+		}
+	}
+}
+
+func TestParseNoDiagnostics(t *testing.T) {
+	res := ParseWith(testMockSchemas, testMockOptions)
+	if res.HasErr() {
+		t.Fatal("unexpected diagnostics: ", res.Diagnostics)
+	}
+}
+
+// testMockSchemasReversed is the mock with every schema and entity
+// declared after what references it
+const testMockSchemasReversed = `# Restaurant
+
+## Order
+- @customer  as co         with required, primary
+- @kitchen.recipe            with required, primary
+- inserted at as ts   with required, system, default:now
+
+## Customer
+- id          as ++
+- first name  as str  with required, 3..30, unique:fl
+- last name   as str  with required, 3..30, unique:fl, unique:ldob
+- dob         as date with unique:ldob
+- last visit  as ts   with required, default:now
+
+# Kitchen
+
+## Recipe
+- @food               with required, primary
+- @ingredient         with required, primary
+- amount      as int  with required
+
+## Food
+- id          as ++
+- name        as str  with required, 3..30, unique
+
+## Ingredient
+- id          as ++
+- name        as str  with required, 3..30, unique
+- eol         as date with required
+- rare        as bool
+- @supplier
+
+## Supplier
+- id          as ++
+- name        as str  with required, 3..30, unique`
+
+func TestParseOutOfOrder(t *testing.T) {
+	res := ParseWith(testMockSchemasReversed, testMockOptions)
+	if res.HasErr() {
+		t.Fatal("unexpected diagnostics: ", res.Diagnostics)
+	}
+
+	inOrder := ParseWith(testMockSchemas, testMockOptions).Schemas
+	for _, tc := range []struct {
+		schema, name string
+	}{
+		{"restaurant", "order"},
+		{"kitchen", "recipe"},
+		{"kitchen", "ingredient"},
+	} {
+		expect := make([]string, 0)
+		for _, attr := range testEntity(t, inOrder, tc.schema, tc.name).Attributes() {
+			expect = append(expect, attr.Name())
+		}
+		got := make([]string, 0)
+		for _, attr := range testEntity(t, res.Schemas, tc.schema, tc.name).Attributes() {
+			got = append(got, attr.Name())
+		}
+		if strings.Join(got, ",") != strings.Join(expect, ",") {
+			t.Fatal("expected the same attributes as in order: ", got, expect)
+		}
+	}
+
+	order := testEntity(t, res.Schemas, "restaurant", "order")
+	if recipe := testAttribute(t, order, "kitchen.recipe"); recipe.ReferenceTo != testEntity(t, res.Schemas, "kitchen", "recipe") {
+		t.Fatal("expected reference to a later schema: ", recipe.String())
+	}
+	customer := testEntity(t, res.Schemas, "restaurant", "customer")
+	if !slices.ContainsFunc(customer.Relations, func(rel model.Relation) bool { return rel.Assoc == order }) {
+		t.Fatal("expected relations of references declared later: ", customer.Relations)
+	}
+
+	testRoundTrip(t, res.Schemas)
+}
+
+func TestParseOutOfOrderDiagnostics(t *testing.T) {
+	const s = `# Kitchen
+
+## Recipe
+- @food       with primary
+- @food as alt
+- @missing
+- @note
+
+## Food
+- id          as ++
+
+## Note
+- done        as bool`
+
+	res := Parse(s)
+
+	expect := []Diagnostic{
+		{Line: 5, Severity: SeverityWarning, Code: CodeReused},
+		{Line: 6, Severity: SeverityError, Code: CodeReference},
+		{Line: 7, Severity: SeverityError, Code: CodeReference},
+	}
+	if len(res.Diagnostics) != len(expect) {
+		t.Fatal("unexpected diagnostic count: ", res.Diagnostics)
+	}
+	for i, d := range res.Diagnostics {
+		if d.Line != expect[i].Line || d.Severity != expect[i].Severity || d.Code != expect[i].Code {
+			t.Fatal("unexpected diagnostic: ", d.String())
+		}
+	}
+
+	// a reused name is renamed, yet references what was written
+	food := testEntity(t, res.Schemas, "kitchen", "food")
+	for _, attr := range testEntity(t, res.Schemas, "kitchen", "recipe").RawAttributes[:2] {
+		if attr.ReferenceTo != food {
+			t.Fatal("expected reference to food: ", attr.String())
+		}
+	}
+}
+
+func TestParseSelfReference(t *testing.T) {
+	const s = `# Forum
+
+## Comment
+- id          as ++
+- body        as text with required
+- @comment    as reply to
+
+## Thread
+- id          as ++
+- @thread     as parent with required
+
+## Node
+- id          as ++
+- @node       as root   with primary`
+
+	res := Parse(s)
+
+	comment := testEntity(t, res.Schemas, "forum", "comment")
+	if reply := testAttribute(t, comment, "comment"); reply.ReferenceTo != comment {
+		t.Fatal("expected reference to itself: ", reply.String())
+	}
+	names := make([]string, 0, 2)
+	for _, rel := range comment.Relations {
+		names = append(names, rel.HasName())
+	}
+	if strings.Join(names, ",") != "parent,children" {
+		t.Fatal("expected a parent and children: ", names)
+	}
+
+	// a tree has a root, so what it references cannot be required
+	expect := []int{10, 14}
+	if len(res.Diagnostics) != len(expect) {
+		t.Fatal("unexpected diagnostic count: ", res.Diagnostics)
+	}
+	for i, d := range res.Diagnostics {
+		if d.Line != expect[i] || !d.IsErr() || d.Code != CodeReference {
+			t.Fatal("unexpected diagnostic: ", d.String())
+		}
+	}
+}
+
+// testMockSchemaDelivery has a chain of composite keys three references
+// deep, from a delivery to the food of a recipe
+const testMockSchemaDelivery = `# Delivery
+
+## Driver
+- id          as ++
+
+## Delivery
+- @driver             with primary
+- @restaurant.order   with primary
+- eta         as ts`
+
+func TestParseDepth(t *testing.T) {
+	// the default flattens the keys of what is referenced, not theirs
+	res := Parse(testMockSchemas)
+	if !res.HasErr() || !errors.Is(testAttribute(t, testEntity(t, res.Schemas, "restaurant", "order"), "kitchen.recipe").Err[0], model.ErrDepthExceeded) {
+		t.Fatal("expected the default depth to err past the keys of what is referenced: ", res.Diagnostics)
+	}
+
+	res = ParseWith(testMockSchemas+"\n"+testMockSchemaDelivery, Options{Depth: 3})
+	if res.HasErr() {
+		t.Fatal("unexpected diagnostics: ", res.Diagnostics)
+	}
+
+	delivery := testEntity(t, res.Schemas, "delivery", "delivery")
+	names := make([]string, 0, 5)
+	for _, attr := range delivery.Primary() {
+		names = append(names, attr.Name())
+	}
+	expect := []string{
+		"driver_id",
+		"restaurant_order_co_id",
+		"restaurant_order_kitchen_recipe_food_id",
+		"restaurant_order_kitchen_recipe_ingredient_id",
+	}
+	if strings.Join(names, ",") != strings.Join(expect, ",") {
+		t.Fatal("unexpected keys: ", names)
+	}
+	for _, attr := range delivery.Primary()[1:] {
+		if attr.Referenced() != testEntity(t, res.Schemas, "restaurant", "order") {
+			t.Fatal("expected key of order: ", attr.Name())
+		}
+	}
+	if name := delivery.Primary()[2].ReferencedName(); name != "kitchen_recipe_food_id" {
+		t.Fatal("expected name of the key in order: ", name)
+	}
+
+	// what is not a key of what is referenced is not followed
+	for _, attr := range testEntity(t, res.Schemas, "kitchen", "recipe").Attributes() {
+		if strings.Contains(attr.Name(), "supplier") {
+			t.Fatal("expected only keys of ingredient: ", attr.Name())
+		}
+	}
+
+	res = ParseWith(testMockSchemas+"\n"+testMockSchemaDelivery, Options{Depth: 2})
+	errs := slices.DeleteFunc(slices.Clone(res.Diagnostics), func(d Diagnostic) bool { return !d.IsErr() })
+	if len(errs) != 1 || errs[0].Code != CodeReference || errs[0].Line != 42 {
+		t.Fatal("expected the reference past the depth to err: ", res.Diagnostics)
+	}
+	if !errors.Is(testAttribute(t, testEntity(t, res.Schemas, "delivery", "delivery"), "restaurant.order").Err[0], model.ErrDepthExceeded) {
+		t.Fatal("expected depth err")
+	}
+	if !strings.Contains(errs[0].Message, "delivery.delivery > restaurant.order > kitchen.recipe > kitchen.food") {
+		t.Fatal("expected the chain of references: ", errs[0].Message)
+	}
+	for _, attr := range testEntity(t, res.Schemas, "delivery", "delivery").Attributes() {
+		if strings.HasPrefix(attr.Name(), "restaurant_order") {
+			t.Fatal("expected reference past the depth to be left out: ", attr.Name())
+		}
+	}
+}
+
+func TestParseWarnings(t *testing.T) {
+	const s = `# Kitchen
+
+## Food
+- id          as ++ with 1..4
+- name        as str with ..20, system
+- name        as str with ..20
+- title       as str with ..20, unique`
+
+	res := Parse(s)
+	if res.HasErr() {
+		t.Fatal("warnings should not be errors: ", res.Diagnostics)
+	}
+
+	attrs := res.Schemas[0].Entities[0].RawAttributes
+
+	if !errors.Is(attrs[0].Warn[0], model.ErrGeneratedClamped) {
+		t.Fatal("expected clamp warning")
+	}
+	if attrs[0].Min.Valid || attrs[0].Max.Valid {
+		t.Fatal("expected serial range to be cleared")
+	}
+	if !errors.Is(attrs[1].Warn[0], model.ErrOptionIgnored) {
+		t.Fatal("expected ignored option warning")
+	}
+	if !errors.Is(attrs[2].Warn[0], model.ErrReusedName) || attrs[2].Name == "name" {
+		t.Fatal("expected rename warning")
+	}
+	// a unique is of a label, without one it is not unique on its own
+	if !errors.Is(attrs[3].Warn[0], model.ErrOptionIgnored) || len(attrs[3].Unique) != 0 {
+		t.Fatal("expected a unique without a label to be ignored: ", attrs[3].Unique)
+	}
+
+	codes := []Code{CodeClamped, CodeOptionIgnored, CodeReused, CodeOptionIgnored}
+	if len(res.Diagnostics) != len(codes) {
+		t.Fatal("unexpected diagnostic count: ", res.Diagnostics)
+	}
+	for i, d := range res.Diagnostics {
+		if d.Severity != SeverityWarning || d.Code != codes[i] {
+			t.Fatal("bad warning: ", d.String())
+		}
+	}
+}
+
+func TestParseEnum(t *testing.T) {
+	const s = `# Blog
+
+## Post
+- status as enum(draft, published, archived, draft) with default:published, r
+- mood   as enum
+- tone   as enum(calm, loud) with d:quiet`
+
+	res := Parse(s)
+	attrs := res.Schemas[0].Entities[0].RawAttributes
+
+	status := attrs[0]
+	if status.Kind != model.AttrKindEnum {
+		t.Fatal("expected enum kind")
+	}
+	if len(status.EnumValues) != 3 || status.EnumValues[1] != "published" {
+		t.Fatal("unexpected enum values: ", status.EnumValues)
+	}
+	if !errors.Is(status.Warn[0], model.ErrEnumReused) {
+		t.Fatal("expected repeated value warning")
+	}
+	if status.DefaultValue != "published" {
+		t.Fatal("expected enum default to be kept")
+	}
+	if status.String() != "- status as enum(draft, published, archived) with required, default:published" {
+		t.Fatal("unexpected string: ", status.String())
+	}
+
+	if !errors.Is(attrs[1].Err[0], model.ErrEnumRequired) {
+		t.Fatal("expected enum values to be required")
+	}
+	if !errors.Is(attrs[2].Err[0], model.ErrMalformedDefault) || attrs[2].HasDefault() {
+		t.Fatal("expected default outside of enum to be rejected")
+	}
+}
+
+func TestParseUUID(t *testing.T) {
+	const s = `# Shop
+
+## Tenant
+- id     as uuid++ with ..4
+- token  as uuid with d:random
+- other  as guid with d:8F14E45F-CEEA-467A-9A36-DEDD4BEA2543
+- bad    as uuid with d:nope`
+
+	res := Parse(s)
+	attrs := res.Schemas[0].Entities[0].RawAttributes
+
+	id := attrs[0]
+	if id.Kind != model.AttrKindGeneratedUUID || !id.Primary || id.Max.Valid {
+		t.Fatal("expected generated uuid to be a primary without options")
+	}
+	if !errors.Is(id.Warn[0], model.ErrGeneratedClamped) {
+		t.Fatal("expected clamp warning")
+	}
+	if attrs[1].Kind != model.AttrKindUUID || attrs[1].DefaultValue != "random" {
+		t.Fatal("expected random uuid default")
+	}
+	if attrs[2].DefaultValue != "8f14e45f-ceea-467a-9a36-dedd4bea2543" {
+		t.Fatal("expected uuid default to be kept: ", attrs[2].DefaultValue)
+	}
+	if !errors.Is(attrs[3].Err[0], model.ErrMalformedDefault) {
+		t.Fatal("expected malformed uuid default")
+	}
+}
+
+func TestParseDocumentKinds(t *testing.T) {
+	const s = `# Docs
+
+## Page
+- id     as ++
+- body   as text with 10..
+- meta   as jsonb with d:{"draft": true}
+- thumb  as bytea with d:DEADBEEF
+- extra  as json with ..20, d:{nope
+- key    as bytes with p`
+
+	res := Parse(s)
+	attrs := res.Schemas[0].Entities[0].RawAttributes
+
+	kinds := []model.AttrKind{model.AttrKindSerial, model.AttrKindText, model.AttrKindJSON, model.AttrKindBytes, model.AttrKindJSON, model.AttrKindBytes}
+	for i, attr := range attrs {
+		if attr.Kind != kinds[i] {
+			t.Fatal("unexpected kind for: ", attr.Name)
+		}
+	}
+
+	if attrs[1].HasErr() || attrs[1].Min.String != "10" {
+		t.Fatal("expected text to not require a max")
+	}
+	if attrs[2].DefaultValue != `{"draft": true}` {
+		t.Fatal("expected json default to be kept")
+	}
+	if attrs[3].DefaultValue != "deadbeef" {
+		t.Fatal("expected hex default to be kept")
+	}
+	if !errors.Is(attrs[4].Warn[0], model.ErrOptionIgnored) || !errors.Is(attrs[4].Err[0], model.ErrMalformedDefault) {
+		t.Fatal("expected json range ignored and default rejected")
+	}
+	if !errors.Is(attrs[5].Err[0], model.ErrPrimaryKind) {
+		t.Fatal("expected bytes to not be primary")
+	}
+}
+
+func TestParseArray(t *testing.T) {
+	const s = `# Blog
+
+## Post
+- id     as ++
+- tags   as str[] with ..20, required
+- moods  as enum(happy, sad)[]
+- ids    as ++[]
+- keys   as int[] with p
+- counts as int[] with d:3`
+
+	res := Parse(s)
+	attrs := res.Schemas[0].Entities[0].RawAttributes
+
+	tags := attrs[1]
+	if !tags.Array || tags.Kind != model.AttrKindString || tags.HasErr() {
+		t.Fatal("expected a string array")
+	}
+	if tags.String() != "- tags as string[] with required, ..20" {
+		t.Fatal("unexpected string: ", tags.String())
+	}
+	if attrs[2].String() != "- moods as enum(happy, sad)[]" {
+		t.Fatal("unexpected string: ", attrs[2].String())
+	}
+
+	again := Parse("# Blog\n\n## Post\n" + tags.String())
+	if !again.Schemas[0].Entities[0].RawAttributes[0].Array {
+		t.Fatal("expected array to round trip")
+	}
+
+	if !errors.Is(attrs[3].Err[0], model.ErrArrayKind) {
+		t.Fatal("expected a generated kind to not be an array")
+	}
+	if !errors.Is(attrs[4].Err[0], model.ErrPrimaryKind) {
+		t.Fatal("expected an array to not be primary")
+	}
+	if !errors.Is(attrs[5].Warn[0], model.ErrOptionIgnored) || attrs[5].HasDefault() {
+		t.Fatal("expected array default to be dropped")
+	}
+}
+
+func TestParseActions(t *testing.T) {
+	const s = `# Shop
+
+## Customer
+- id as ++
+
+## Order
+- id    as ++
+- @customer with required, cascade
+- note  as text with cascade
+- owner as int with on delete:explode
+
+## Review
+- id as ++
+- @customer with ON  DELETE:set_null, on update:Cascade
+
+## Line
+- id as ++
+- @order with required, on delete:set null`
+
+	res := Parse(s)
+	order := res.Schemas[0].Entities[1]
+
+	customer := order.RawAttributes[1]
+	if customer.OnDelete != model.RefActionCascade || customer.OnUpdate != model.RefActionNone {
+		t.Fatal("expected cascade to be on delete: ", customer.String())
+	}
+	if customer.String() != "- @customer with required, cascade" {
+		t.Fatal("unexpected string: ", customer.String())
+	}
+	if note := order.RawAttributes[2]; !errors.Is(note.Warn[0], model.ErrOptionIgnored) || note.OnDelete != model.RefActionNone {
+		t.Fatal("expected an action to be dropped from a column")
+	}
+	if owner := order.RawAttributes[3]; !errors.Is(owner.Warn[0], model.ErrOptionIgnored) {
+		t.Fatal("expected an unknown action to be ignored")
+	}
+
+	review := res.Schemas[0].Entities[2].RawAttributes[1]
+	if review.OnDelete != model.RefActionSetNull || review.OnUpdate != model.RefActionCascade {
+		t.Fatal("expected actions regardless of case and spacing: ", review.String())
+	}
+	if review.String() != "- @customer with on delete:set null, on update:cascade" {
+		t.Fatal("unexpected string: ", review.String())
+	}
+
+	line := res.Schemas[0].Entities[3].RawAttributes[1]
+	if !errors.Is(line.Err[0], model.ErrSetNullRequired) {
+		t.Fatal("expected a required reference to not be set null")
+	}
+
+	var codes []Code
+	for _, d := range res.Diagnostics {
+		codes = append(codes, d.Code)
+	}
+	if strings.Join(toStrings(codes), ",") != "option-ignored,option-ignored,action" {
+		t.Fatal("unexpected diagnostics: ", res.Diagnostics)
+	}
+}
+
+func TestParseEntityOptions(t *testing.T) {
+	const s = `# Shop
+
+## Customer with timestamps, Soft  Delete, versioned
+- id   as ++
+- name as str with required, ..30
+
+## Order with archived
+- id as ++
+
+## Review with versioned
+- id      as ++
+- version as int`
+
+	res := Parse(s)
+	customer := res.Schemas[0].Entities[0]
+	if !customer.Timestamps || !customer.SoftDelete || !customer.Versioned {
+		t.Fatal("expected every option: ", customer.String())
+	}
+	if customer.String() != "## customer with timestamps, soft delete, versioned" {
+		t.Fatal("unexpected string: ", customer.String())
+	}
+
+	names := make([]string, 0, len(customer.RawAttributes))
+	for _, attr := range customer.RawAttributes {
+		names = append(names, attr.Name)
+	}
+	if strings.Join(names, ",") != "id,name,created_at,updated_at,deleted_at,version" {
+		t.Fatal("expected the attributes of the options after those written: ", names)
+	}
+	if deleted := customer.RawAttributes[4]; !deleted.Managed || deleted.Required.Bool {
+		t.Fatal("expected an optional managed deleted at: ", deleted.String())
+	}
+	for _, attr := range customer.AttributesToUpdate() {
+		if attr.Source.Managed {
+			t.Fatal("expected the store to set what an option adds: ", attr.Name())
+		}
+	}
+
+	if order := res.Schemas[0].Entities[1]; order.Timestamps || len(order.RawAttributes) != 1 {
+		t.Fatal("expected an unknown option to add nothing")
+	}
+
+	review := res.Schemas[0].Entities[2]
+	if !errors.Is(review.RawAttributes[1].Err[0], model.ErrOptionAttribute) {
+		t.Fatal("expected a written attribute named as one an option adds to err")
+	}
+
+	var codes []Code
+	for _, d := range res.Diagnostics {
+		codes = append(codes, d.Code)
+	}
+	if strings.Join(toStrings(codes), ",") != "option-ignored,reused" {
+		t.Fatal("unexpected diagnostics: ", res.Diagnostics)
+	}
+
+	// the options are written, not what they add
+	formatted := Format(res.Schemas[:1])
+	if strings.Count(formatted, "created_at") != 0 || Parse(formatted).Schemas[0].Entities[0].String() != customer.String() {
+		t.Fatal("unexpected format: ", formatted)
+	}
+}
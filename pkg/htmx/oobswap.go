@@ -5,8 +5,13 @@ package htmx
 import (
 	"bytes"
 	"io"
+	"io/fs"
 	"net/http"
+	"os"
+	"path/filepath"
 	"text/template"
+
+	"github.com/Isaac799/devtoolbox/pkg/tmplcache"
 )
 
 // OobSwapper is a buffer designed around parsing templates
@@ -51,20 +56,52 @@ type OobSwap = func(*OobSwapper) error
 
 // NewOobSwap provides a new out of band swap. Template option fns enable direct
 // modification the the template before execution, to enable attaching things like fn map.
+//
+// Deprecated: the template is parsed on every swap, use NewOobSwapCached.
 func NewOobSwap(
 	templatePath, templateName string, data any,
 	templateOptions ...func(*template.Template) error,
+) OobSwap {
+	fsys := os.DirFS(filepath.Dir(templatePath))
+	return NewOobSwapCached(tmplcache.New(true), fsys, filepath.Base(templatePath), templateName, data, templateOptions...)
+}
+
+// NewOobSwapFS is like NewOobSwap, but the template path is read from a file system
+// such as an embed.FS.
+//
+// Deprecated: the template is parsed on every swap, use NewOobSwapCached.
+func NewOobSwapFS(
+	fsys fs.FS, templatePath, templateName string, data any,
+	templateOptions ...func(*template.Template) error,
+) OobSwap {
+	return NewOobSwapCached(tmplcache.New(true), fsys, templatePath, templateName, data, templateOptions...)
+}
+
+// NewOobSwapCached provides a new out of band swap of a template read from a file system
+// such as an embed.FS. The template is kept in a cache keyed by its path, so it is only
+// parsed once. Template option fns enable direct modification of the template, to enable
+// attaching things like fn map. They only run when parsing, so they should not depend on
+// anything that changes between swaps.
+func NewOobSwapCached(
+	cache *tmplcache.Cache, fsys fs.FS, templatePath, templateName string, data any,
+	templateOptions ...func(*template.Template) error,
 ) OobSwap {
 	return OobSwap(func(oobSwapper *OobSwapper) error {
-		tmpl, err := template.ParseFiles(templatePath)
-		if err != nil {
-			return err
-		}
+		tmpl, err := cache.Get(templatePath, func() (*template.Template, error) {
+			tmpl, err := template.ParseFS(fsys, templatePath)
+			if err != nil {
+				return nil, err
+			}
 
-		for _, templateOpt := range templateOptions {
-			if err := templateOpt(tmpl); err != nil {
-				return err
+			for _, templateOpt := range templateOptions {
+				if err := templateOpt(tmpl); err != nil {
+					return nil, err
+				}
 			}
+			return tmpl, nil
+		})
+		if err != nil {
+			return err
 		}
 
 		if err := tmpl.ExecuteTemplate(oobSwapper.buff, templateName, data); err != nil {
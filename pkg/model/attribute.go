@@ -3,6 +3,8 @@ package model
 import (
 	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"slices"
@@ -33,8 +35,33 @@ const (
 	AttrKindReal
 	AttrKindDecimal
 	AttrKindMoney
+	AttrKindEnum
+	AttrKindUUID
+	AttrKindGeneratedUUID
+	AttrKindText
+	AttrKindJSON
+	AttrKindBytes
 )
 
+// Generated is true for kinds whose value the database
+// provides, making them primary and without options
+func (k AttrKind) Generated() bool {
+	return k == AttrKindSerial || k == AttrKindGeneratedUUID
+}
+
+// Base is the kind a generated kind is stored as when it is referenced,
+// since only the source of a reference generates its value
+func (k AttrKind) Base() AttrKind {
+	switch k {
+	case AttrKindSerial:
+		return AttrKindInt
+	case AttrKindGeneratedUUID:
+		return AttrKindUUID
+	default:
+		return k
+	}
+}
+
 var _attrKind = map[AttrKind]string{
 	AttrKindNone:      "???",
 	AttrKindReference: "???",
@@ -51,6 +78,57 @@ var _attrKind = map[AttrKind]string{
 	AttrKindReal:      "real",
 	AttrKindDecimal:   "decimal",
 	AttrKindMoney:     "money",
+	AttrKindEnum:      "enum",
+
+	AttrKindUUID:          "uuid",
+	AttrKindGeneratedUUID: "uuid++",
+	AttrKindText:          "text",
+	AttrKindJSON:          "json",
+	AttrKindBytes:         "bytes",
+}
+
+// RefAction is what the database does to a reference when the
+// record it references is deleted or has its key updated
+type RefAction int
+
+// recognized actions, none leaves it to the database default
+const (
+	RefActionNone RefAction = iota
+	RefActionCascade
+	RefActionSetNull
+	RefActionSetDefault
+	RefActionRestrict
+)
+
+var _refAction = map[RefAction]string{
+	RefActionNone:       "no action",
+	RefActionCascade:    "cascade",
+	RefActionSetNull:    "set null",
+	RefActionSetDefault: "set default",
+	RefActionRestrict:   "restrict",
+}
+
+// String is the action as it is written in an option, 'on delete:set null'
+func (a RefAction) String() string {
+	return _refAction[a]
+}
+
+// SQL is the action as it is written after ON DELETE or ON UPDATE
+func (a RefAction) SQL() string {
+	return strings.ToUpper(_refAction[a])
+}
+
+// ParseRefAction provides the action written, ignoring case and
+// allowing 'set_null' or 'set-null' for 'set null'
+func ParseRefAction(s string) (RefAction, bool) {
+	s = strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(s))
+	s = strings.Join(strings.Fields(s), " ")
+	for a, name := range _refAction {
+		if name == s {
+			return a, true
+		}
+	}
+	return RefActionNone, false
 }
 
 // AttributeRaw is a metric in an entity, like a column in a table
@@ -58,6 +136,8 @@ type AttributeRaw struct {
 	ID string
 
 	Kind AttrKind
+	// Array holds a list of its kind rather than one value
+	Array bool
 
 	Primary      bool
 	Name         string
@@ -65,10 +145,21 @@ type AttributeRaw struct {
 	DefaultValue string
 
 	Unique []string
-	Err    []error
+	// EnumValues are the closed set of values an enum kind may hold
+	EnumValues []string
+	Err        []error
+	// Warn are things that were auto-corrected, and do
+	// not block generation like Err does
+	Warn []error
 
 	Parent      *Entity
 	ReferenceTo *Entity
+	// OnDelete and OnUpdate are what happens to a reference as
+	// what it references changes, only relevant to a reference
+	OnDelete RefAction
+	OnUpdate RefAction
+	// Managed is added by an option of its entity rather than written
+	Managed bool
 
 	Validation
 }
@@ -80,6 +171,7 @@ func NewAttribute(parent *Entity) *AttributeRaw {
 		Name:   internal.NewFallbackName(),
 		Unique: make([]string, 0, 2),
 		Err:    make([]error, 0, 2),
+		Warn:   make([]error, 0, 2),
 		Parent: parent,
 	}
 }
@@ -98,6 +190,11 @@ func (attr *AttributeRaw) UniqueLabels() string {
 	return strings.Join(attr.Unique, ", ")
 }
 
+// EnumLabels allows string rep of enum values, for templating entry
+func (attr *AttributeRaw) EnumLabels() string {
+	return strings.Join(attr.EnumValues, ", ")
+}
+
 // String provides parsable text to generate itself
 func (attr *AttributeRaw) String() string {
 	opts := []string{}
@@ -107,6 +204,14 @@ func (attr *AttributeRaw) String() string {
 	if attr.Validation.Required.Bool {
 		opts = append(opts, "required")
 	}
+	if attr.OnDelete == RefActionCascade {
+		opts = append(opts, "cascade")
+	} else if attr.OnDelete != RefActionNone {
+		opts = append(opts, fmt.Sprintf("on delete:%s", attr.OnDelete))
+	}
+	if attr.OnUpdate != RefActionNone {
+		opts = append(opts, fmt.Sprintf("on update:%s", attr.OnUpdate))
+	}
 
 	if len(attr.Validation.Min.String) > 0 || len(attr.Validation.Max.String) > 0 {
 		opts = append(opts, fmt.Sprintf("%s..%s", attr.Validation.Min.String, attr.Validation.Max.String))
@@ -128,26 +233,38 @@ func (attr *AttributeRaw) String() string {
 	parts := make([]string, 0, 10)
 
 	if attr.ReferenceTo != nil {
+		// qualified by schema when it is not the schema of this attribute
+		to := attr.ReferenceTo.Name
+		if attr.Parent != nil && attr.ReferenceTo.Parent != nil && attr.Parent.Parent != attr.ReferenceTo.Parent {
+			to = fmt.Sprintf("%s.%s", attr.ReferenceTo.Parent.Name, to)
+		}
 		if len(attr.Alias) > 0 {
 			parts = append(parts,
 				"-",
-				fmt.Sprintf("@%s", attr.ReferenceTo.Name),
+				fmt.Sprintf("@%s", to),
 				"as",
 				attr.Alias,
 			)
 		} else {
 			parts = append(parts,
 				"-",
-				fmt.Sprintf("@%s", attr.ReferenceTo.Name),
+				fmt.Sprintf("@%s", to),
 			)
 		}
 
 	} else {
+		kind := _attrKind[attr.Kind]
+		if attr.Kind == AttrKindEnum {
+			kind = fmt.Sprintf("%s(%s)", kind, attr.EnumLabels())
+		}
+		if attr.Array {
+			kind += "[]"
+		}
 		parts = append(parts,
 			"-",
 			attr.Name,
 			"as",
-			_attrKind[attr.Kind],
+			kind,
 		)
 	}
 
@@ -191,6 +308,31 @@ func (attr *AttributeRaw) ErrString() string {
 	return sb.String()
 }
 
+// AppendWarn simplifies adding warnings
+func (attr *AttributeRaw) AppendWarn(err error) {
+	if attr.Warn == nil {
+		attr.Warn = make([]error, 0, 1)
+	}
+	attr.Warn = append(attr.Warn, err)
+}
+
+// HasWarn makes checking for warnings easier
+func (attr *AttributeRaw) HasWarn() bool {
+	return len(attr.Warn) > 0
+}
+
+// WarnString provides a template friendly way to print warnings
+func (attr *AttributeRaw) WarnString() string {
+	sb := strings.Builder{}
+	for i, err := range attr.Warn {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
 // HasDefault just checks is default is relevant. Used in templating.
 func (attr *AttributeRaw) HasDefault() bool {
 	return len(attr.DefaultValue) > 0
@@ -208,14 +350,100 @@ func (attr *AttributeRaw) MaybeRequireValidation() {
 		if len(attr.Max.String) == 0 {
 			attr.AppendErr(ErrBitSizeRequired)
 		}
+	case AttrKindEnum:
+		if len(attr.EnumValues) == 0 {
+			attr.AppendErr(ErrEnumRequired)
+		}
+	}
+}
+
+// EnsureValidPrimary will error an attr whose kind cannot be
+// used as a key, such as a document or a blob
+func (attr *AttributeRaw) EnsureValidPrimary() {
+	if !attr.Primary {
+		return
+	}
+	if attr.Array {
+		attr.AppendErr(ErrPrimaryKind)
+		return
+	}
+	switch attr.Kind {
+	case AttrKindJSON, AttrKindBytes:
+		attr.AppendErr(ErrPrimaryKind)
+	}
+}
+
+// EnsureValidArray will error an array of a kind that cannot be listed,
+// and drop a default since an option cannot hold a list of values.
+// A range applies to each element rather than the list.
+func (attr *AttributeRaw) EnsureValidArray() {
+	if !attr.Array {
+		return
+	}
+	switch attr.Kind {
+	case AttrKindReference, AttrKindSerial, AttrKindGeneratedUUID, AttrKindJSON:
+		attr.AppendErr(fmt.Errorf("%w: %s", ErrArrayKind, _attrKind[attr.Kind]))
+	}
+	if len(attr.DefaultValue) > 0 {
+		attr.AppendWarn(fmt.Errorf("%w: default:%s", ErrOptionIgnored, attr.DefaultValue))
+		attr.DefaultValue = ""
+	}
+}
+
+// EnsureValidAction will drop an action from an attribute that is not a
+// reference, and error one that would set null a reference that cannot be
+func (attr *AttributeRaw) EnsureValidAction() {
+	if attr.Kind != AttrKindReference {
+		if attr.OnDelete != RefActionNone {
+			attr.AppendWarn(fmt.Errorf("%w: on delete:%s", ErrOptionIgnored, attr.OnDelete))
+		}
+		if attr.OnUpdate != RefActionNone {
+			attr.AppendWarn(fmt.Errorf("%w: on update:%s", ErrOptionIgnored, attr.OnUpdate))
+		}
+		attr.OnDelete = RefActionNone
+		attr.OnUpdate = RefActionNone
+		return
+	}
+	if !attr.Primary && !attr.Required.Bool {
+		return
+	}
+	if attr.OnDelete == RefActionSetNull || attr.OnUpdate == RefActionSetNull {
+		attr.AppendErr(ErrSetNullRequired)
+	}
+}
+
+// SanitizeEnumValues trims enum values, dropping any that
+// are empty and warning about any that are repeated
+func (attr *AttributeRaw) SanitizeEnumValues() {
+	if attr.Kind != AttrKindEnum {
+		attr.EnumValues = nil
+		return
+	}
+
+	values := make([]string, 0, len(attr.EnumValues))
+	for _, s := range attr.EnumValues {
+		s = strings.TrimSpace(s)
+		if len(s) == 0 {
+			continue
+		}
+		if slices.Contains(values, s) {
+			attr.AppendWarn(fmt.Errorf("%w: %s dropped", ErrEnumReused, s))
+			continue
+		}
+		values = append(values, s)
 	}
+	attr.EnumValues = values
 }
 
-// SanativeSerialKind will set and unset values accordingly
-func (attr *AttributeRaw) SanativeSerialKind() {
-	if attr.Kind != AttrKindSerial {
+// SanativeGeneratedKind will set and unset values accordingly for a
+// generated kind, warning if it had to clamp options that were provided
+func (attr *AttributeRaw) SanativeGeneratedKind() {
+	if !attr.Kind.Generated() {
 		return
 	}
+	if attr.Min.Valid || attr.Max.Valid || (attr.Required.Valid && !attr.Required.Bool) {
+		attr.AppendWarn(fmt.Errorf("%w: %s", ErrGeneratedClamped, _attrKind[attr.Kind]))
+	}
 	attr.Primary = true
 	attr.Required = sql.NullBool{Valid: true, Bool: true}
 	attr.Min = sql.NullString{Valid: false}
@@ -236,9 +464,8 @@ func (attr *AttributeRaw) EnsureValidAlias(ent *Entity) {
 	}
 
 	if slices.Contains(consumedAlias, attr.Alias) {
+		attr.AppendWarn(fmt.Errorf("%w: %s dropped", ErrReusedAlias, attr.Alias))
 		attr.Alias = ""
-		// todo warnings
-		// attr.AppendErr(ErrReusedAlias)
 	}
 }
 
@@ -253,9 +480,9 @@ func (attr *AttributeRaw) EnsureValidName(ent *Entity) {
 	}
 
 	if slices.Contains(consumed, attr.Name) {
-		attr.Name = internal.NewFallbackName()
-		// todo warnings
-		// attr.AppendErr(ErrReusedName)
+		fallback := internal.NewFallbackName()
+		attr.AppendWarn(fmt.Errorf("%w: %s renamed to %s", ErrReusedName, attr.Name, fallback))
+		attr.Name = fallback
 	}
 }
 
@@ -270,6 +497,32 @@ func (attr *AttributeRaw) ClearReferenceErr() {
 	attr.Err = keepErrs
 }
 
+// clearDepthErr removes an error of references being too deep, as it
+// depends on what is referenced rather than the attribute
+func (attr *AttributeRaw) clearDepthErr() {
+	keepErrs := make([]error, 0, len(attr.Err))
+	for _, err := range attr.Err {
+		if errors.Is(err, ErrDepthExceeded) {
+			continue
+		}
+		keepErrs = append(keepErrs, err)
+	}
+	attr.Err = keepErrs
+}
+
+// clearOptionErr removes an error of having the name of an attribute an
+// entity option adds, as it depends on the options of the entity
+func (attr *AttributeRaw) clearOptionErr() {
+	keepErrs := make([]error, 0, len(attr.Err))
+	for _, err := range attr.Err {
+		if errors.Is(err, ErrOptionAttribute) {
+			continue
+		}
+		keepErrs = append(keepErrs, err)
+	}
+	attr.Err = keepErrs
+}
+
 func (attr *AttributeRaw) EnsureValidReference(schemas []*Schema) {
 	before, after, isFullRef := strings.Cut(attr.Name, ".")
 
@@ -277,33 +530,22 @@ func (attr *AttributeRaw) EnsureValidReference(schemas []*Schema) {
 		for _, ent := range sch.Entities {
 			// ID is set on gui delta
 			if ent.ID == before {
-				if !ent.HasPrimary() {
-					attr.Err = append(attr.Err, errors.Join(ErrReference, ErrEmptyPrimary))
-					return
+				if attr.setReference(ent) {
+					attr.Name = fmt.Sprintf("%s.%s", ent.Parent.Name, ent.Name)
 				}
-				attr.ReferenceTo = ent
-				attr.Name = fmt.Sprintf("%s.%s", ent.Parent.Name, ent.Name)
 				return
 			}
 
 			if isFullRef {
 				if before == sch.Name && after == ent.Name {
-					if !ent.HasPrimary() {
-						attr.Err = append(attr.Err, errors.Join(ErrReference, ErrEmptyPrimary))
-						return
-					}
-					attr.ReferenceTo = ent
+					attr.setReference(ent)
 					return
 				}
 				continue
 			}
 
 			if before == ent.Name {
-				if !ent.HasPrimary() {
-					attr.Err = append(attr.Err, errors.Join(ErrReference, ErrEmptyPrimary))
-					return
-				}
-				attr.ReferenceTo = ent
+				attr.setReference(ent)
 				return
 			}
 		}
@@ -312,11 +554,34 @@ func (attr *AttributeRaw) EnsureValidReference(schemas []*Schema) {
 	attr.AppendErr(errors.Join(ErrReference, ErrNonExistent))
 }
 
+// setReference references an entity if it can be, reporting if it was.
+// A reference to its own entity is optional, as a tree has a root.
+func (attr *AttributeRaw) setReference(ent *Entity) bool {
+	if !ent.HasPrimary() {
+		attr.Err = append(attr.Err, errors.Join(ErrReference, ErrEmptyPrimary))
+		return false
+	}
+	if ent == attr.Parent && (attr.Primary || attr.Required.Bool) {
+		attr.Err = append(attr.Err, errors.Join(ErrReference, ErrSelfRequired))
+		return false
+	}
+	attr.ReferenceTo = ent
+	return true
+}
+
 func (attr *AttributeRaw) EnsureValidRange() {
 	minStr := attr.Min.String
 	maxStr := attr.Max.String
 
 	switch attr.Kind {
+	case AttrKindEnum, AttrKindJSON:
+		// the set of values is the only range an enum has,
+		// and a document has no length worth limiting
+		if attr.Min.Valid || attr.Max.Valid {
+			attr.AppendWarn(fmt.Errorf("%w: %s..%s", ErrOptionIgnored, minStr, maxStr))
+		}
+		attr.Min = sql.NullString{String: "", Valid: false}
+		attr.Max = sql.NullString{String: "", Valid: false}
 	case AttrKindBit:
 		attr.Min = sql.NullString{String: "", Valid: false}
 		_, maxErr := strconv.Atoi(maxStr)
@@ -359,7 +624,7 @@ func (attr *AttributeRaw) EnsureValidRange() {
 		if len(minStr) > 0 && len(maxStr) > 0 && max.Before(min) {
 			attr.AppendErr(ErrRangeMaxUnderMin)
 		}
-	case AttrKindDecimal, AttrKindReal, AttrKindFloat, AttrKindInt, AttrKindMoney, AttrKindSerial, AttrKindString, AttrKindChar:
+	case AttrKindDecimal, AttrKindReal, AttrKindFloat, AttrKindInt, AttrKindMoney, AttrKindSerial, AttrKindString, AttrKindChar, AttrKindText, AttrKindBytes:
 		min, minErr := strconv.ParseFloat(minStr, 64)
 		max, maxErr := strconv.ParseFloat(maxStr, 64)
 		if len(minStr) > 0 && minErr != nil {
@@ -373,7 +638,7 @@ func (attr *AttributeRaw) EnsureValidRange() {
 		}
 	}
 
-	if attr.Kind == AttrKindString || attr.Kind == AttrKindChar || attr.Kind == AttrKindBit {
+	if attr.Kind == AttrKindString || attr.Kind == AttrKindChar || attr.Kind == AttrKindBit || attr.Kind == AttrKindText || attr.Kind == AttrKindBytes {
 		max, maxErr := strconv.ParseFloat(maxStr, 64)
 		if len(maxStr) > 0 && maxErr == nil && max < 0 {
 			attr.AppendErr(ErrRangeMaxBelowZero)
@@ -405,6 +670,7 @@ func (attr *AttributeRaw) SanitizeDefaultValue() {
 			attr.AppendErr(ErrMalformedDefault)
 			break
 		}
+		final = candidate
 	case AttrKindString:
 		final = candidate
 	case AttrKindChar:
@@ -432,6 +698,7 @@ func (attr *AttributeRaw) SanitizeDefaultValue() {
 			attr.AppendErr(ErrMalformedDefault)
 			break
 		}
+		final = candidate
 	case AttrKindDate:
 		if candidate == "now" {
 			final = candidate
@@ -471,6 +738,41 @@ func (attr *AttributeRaw) SanitizeDefaultValue() {
 			attr.AppendErr(ErrMalformedDefault)
 			break
 		}
+		final = candidate
+	case AttrKindUUID:
+		if candidate == "random" {
+			final = candidate
+			break
+		}
+		if !IsUUID(candidate) {
+			attr.AppendErr(ErrMalformedDefault)
+			break
+		}
+		final = strings.ToLower(candidate)
+	case AttrKindGeneratedUUID:
+		attr.AppendErr(ErrMalformedDefault)
+	case AttrKindText:
+		final = candidate
+	case AttrKindJSON:
+		if !json.Valid([]byte(candidate)) {
+			attr.AppendErr(ErrMalformedDefault)
+			break
+		}
+		final = candidate
+	case AttrKindBytes:
+		// given as hex, so 'deadbeef'
+		_, err := hex.DecodeString(candidate)
+		if err != nil {
+			attr.AppendErr(ErrMalformedDefault)
+			break
+		}
+		final = strings.ToLower(candidate)
+	case AttrKindEnum:
+		if !slices.Contains(attr.EnumValues, candidate) {
+			attr.AppendErr(ErrMalformedDefault)
+			break
+		}
+		final = candidate
 	}
 
 	for _, err := range attr.Err {
@@ -482,3 +784,23 @@ func (attr *AttributeRaw) SanitizeDefaultValue() {
 
 	attr.DefaultValue = strings.TrimSpace(final)
 }
+
+// IsUUID checks a string is in the canonical 8-4-4-4-12 hex form
+func IsUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, r := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if r != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return false
+			}
+		}
+	}
+	return true
+}
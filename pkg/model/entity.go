@@ -2,7 +2,9 @@ package model
 
 import (
 	"crypto/rand"
+	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Isaac799/devtoolbox/internal"
@@ -23,6 +25,17 @@ type Entity struct {
 	// all attributes after cached
 	ar []*Attribute
 
+	// depth is how many references deep keys are flattened, the default if zero
+	depth int
+
+	// Timestamps adds when a record was created and last updated
+	Timestamps bool
+	// SoftDelete marks a record as deleted rather than removing it
+	SoftDelete bool
+	// Versioned counts the updates of a record, so an update
+	// made from a stale read of it is a conflict
+	Versioned bool
+
 	// Relations provides all the relations for a schema, has setter method.
 	// Should be set before using templates
 	Relations []Relation
@@ -57,9 +70,95 @@ func (ent *Entity) Path() string {
 	}, "/")
 }
 
+// names of the attributes the options of an entity add
+const (
+	AttrNameCreatedAt = "created_at"
+	AttrNameUpdatedAt = "updated_at"
+	AttrNameDeletedAt = "deleted_at"
+	AttrNameVersion   = "version"
+)
+
 // String provides parsable text to generate itself
 func (ent *Entity) String() string {
-	return fmt.Sprintf("## %s", ent.Name)
+	opts := make([]string, 0, 3)
+	if ent.Timestamps {
+		opts = append(opts, "timestamps")
+	}
+	if ent.SoftDelete {
+		opts = append(opts, "soft delete")
+	}
+	if ent.Versioned {
+		opts = append(opts, "versioned")
+	}
+	if len(opts) == 0 {
+		return fmt.Sprintf("## %s", ent.Name)
+	}
+	return fmt.Sprintf("## %s with %s", ent.Name, strings.Join(opts, ", "))
+}
+
+// optionAttributes are the attributes the options of an entity add
+func (ent *Entity) optionAttributes() []*AttributeRaw {
+	attrs := make([]*AttributeRaw, 0, 4)
+	add := func(name string, kind AttrKind, required bool, defaultValue string) {
+		attr := NewAttribute(ent)
+		attr.Name = name
+		attr.Kind = kind
+		attr.DefaultValue = defaultValue
+		attr.Managed = true
+		if required {
+			attr.Required = sql.NullBool{Bool: true, Valid: true}
+		}
+		attrs = append(attrs, attr)
+	}
+	if ent.Timestamps {
+		add(AttrNameCreatedAt, AttrKindTimestamp, true, "now")
+		add(AttrNameUpdatedAt, AttrKindTimestamp, true, "now")
+	}
+	if ent.SoftDelete {
+		add(AttrNameDeletedAt, AttrKindTimestamp, false, "")
+	}
+	if ent.Versioned {
+		add(AttrNameVersion, AttrKindInt, true, "1")
+	}
+	return attrs
+}
+
+// EnsureOptionAttributes adds the attributes the options of an entity need,
+// after those written, and removes those of options it no longer has.
+// An attribute written with the name of one is in error.
+func (ent *Entity) EnsureOptionAttributes() {
+	wanted := ent.optionAttributes()
+	isWanted := func(attr *AttributeRaw) bool {
+		return slices.ContainsFunc(wanted, func(w *AttributeRaw) bool {
+			return w.Name == attr.Name
+		})
+	}
+
+	attrs := make([]*AttributeRaw, 0, len(ent.RawAttributes)+len(wanted))
+	for _, attr := range ent.RawAttributes {
+		if attr.Managed {
+			if isWanted(attr) {
+				attrs = append(attrs, attr)
+			}
+			continue
+		}
+		attr.clearOptionErr()
+		if isWanted(attr) {
+			attr.AppendErr(fmt.Errorf("%w: %s", ErrOptionAttribute, attr.Name))
+		}
+		attrs = append(attrs, attr)
+	}
+	for _, w := range wanted {
+		kept := slices.ContainsFunc(attrs, func(attr *AttributeRaw) bool {
+			return attr.Managed && attr.Name == w.Name
+		})
+		if !kept {
+			attrs = append(attrs, w)
+		}
+	}
+
+	ent.RawAttributes = attrs
+	ent.ClearCache()
 }
 
 // Attribute is a attribute discovered curing recursion.
@@ -89,6 +188,27 @@ func (attr *Attribute) Name() string {
 	return strings.Trim(b, "_")
 }
 
+// Referenced is the entity a key found through a reference is of, the one
+// its source references. Final is of the entity the key is declared in,
+// which is further along if the key is itself a reference. A key of the
+// entity itself is of that entity.
+func (attr *Attribute) Referenced() *Entity {
+	if attr.DirectChild {
+		return attr.Final.Parent
+	}
+	return attr.Source.ReferenceTo
+}
+
+// ReferencedName is the name of a key as it is named in the entity
+// referenced, being the path past its source
+func (attr *Attribute) ReferencedName() string {
+	if attr.DirectChild {
+		return attr.Name()
+	}
+	_, inner, _ := strings.Cut(strings.Trim(attr.Path, "/"), "/")
+	return strings.NewReplacer("/", "_", ".", "_").Replace(inner)
+}
+
 // AsName is a naming for sql column aliasing to distinguish on column name overlap
 func (attr *Attribute) AsName() string {
 	return strcase.ToSnake(fmt.Sprintf("%s_%s", attr.Source.Parent.Name, attr.Name()))
@@ -96,7 +216,7 @@ func (attr *Attribute) AsName() string {
 
 func (ent *Entity) attributes(n, max int, path string, collection *[]*Attribute, source *AttributeRaw) []*Attribute {
 	if n > max {
-		// todo attach info err to attr or entity
+		// deeper than this is reported by SetDepth on the source
 		return *collection
 	}
 
@@ -105,6 +225,11 @@ func (ent *Entity) attributes(n, max int, path string, collection *[]*Attribute,
 			continue
 		}
 
+		// only the key of what is referenced is flattened
+		if n > 0 && !attr.Primary {
+			continue
+		}
+
 		s := attr.Name
 		if len(attr.Alias) > 0 {
 			s = attr.Alias
@@ -126,10 +251,6 @@ func (ent *Entity) attributes(n, max int, path string, collection *[]*Attribute,
 			continue
 		}
 
-		if n > 0 && !attr.Primary {
-			continue
-		}
-
 		ref := Reference{
 			Path:          p,
 			Source:        src,
@@ -149,6 +270,14 @@ func (ent *Entity) attributes(n, max int, path string, collection *[]*Attribute,
 	return *collection
 }
 
+// Depth is how many references deep the keys of an entity are flattened
+func (ent *Entity) Depth() int {
+	if ent.depth > 0 {
+		return ent.depth
+	}
+	return DefaultDepth
+}
+
 // Attributes provides recursive list of attributes, so references are
 // made primitive
 func (ent *Entity) Attributes() []*Attribute {
@@ -156,7 +285,7 @@ func (ent *Entity) Attributes() []*Attribute {
 		return ent.ar
 	}
 
-	max := 1
+	max := ent.Depth()
 
 	attrs := make([]*Attribute, 0, len(ent.RawAttributes)*max)
 
@@ -185,6 +314,10 @@ func (ent *Entity) AttributesToCreate() []*Attribute {
 		if len(attr.Source.DefaultValue) > 0 {
 			continue
 		}
+		// the store sets what an option of the entity adds
+		if attr.Source.Managed {
+			continue
+		}
 		ok = append(ok, attr)
 	}
 
@@ -207,6 +340,10 @@ func (ent *Entity) AttributesToUpdate() []*Attribute {
 		if len(attr.Source.DefaultValue) > 0 {
 			continue
 		}
+		// the store sets what an option of the entity adds
+		if attr.Source.Managed {
+			continue
+		}
 		ok = append(ok, attr)
 	}
 
@@ -309,6 +446,14 @@ func (ent *Entity) HasValidity() bool {
 		if val.Max.Valid || val.Min.Valid || val.Required.Valid {
 			return true
 		}
+		// an enum is always checked against its values
+		if v.Final.Kind == AttrKindEnum {
+			return true
+		}
+		// each element of an array is checked
+		if v.Final.Array {
+			return true
+		}
 	}
 	return false
 }
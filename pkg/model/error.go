@@ -6,12 +6,19 @@ var (
 	ErrMalformedDefault = errors.New("unacceptable default value")
 	ErrMaxLenRequired   = errors.New("upper range is required")
 	ErrBitSizeRequired  = errors.New("bit size is required")
+	ErrEnumRequired     = errors.New("enum values are required")
+	ErrPrimaryKind      = errors.New("kind cannot be primary")
+	ErrArrayKind        = errors.New("kind cannot be an array")
+	ErrSetNullRequired  = errors.New("required reference cannot be set null")
+	ErrOptionAttribute  = errors.New("name is of an attribute an entity option adds")
 
 	ErrReference = errors.New("reference error")
 
-	ErrNonExistent  = errors.New("cannot find reference")
-	ErrEmptyPrimary = errors.New("missing primary")
-	ErrUnset        = errors.New("is not set")
+	ErrNonExistent   = errors.New("cannot find reference")
+	ErrEmptyPrimary  = errors.New("missing primary")
+	ErrSelfRequired  = errors.New("reference to its own entity cannot be required")
+	ErrDepthExceeded = errors.New("references deeper than")
+	ErrUnset         = errors.New("is not set")
 
 	ErrMissingMax        = errors.New("missing max")
 	ErrRangeMinMalformed = errors.New("range invalid: min malformed")
@@ -19,3 +26,13 @@ var (
 	ErrRangeMaxUnderMin  = errors.New("range invalid: max under min")
 	ErrRangeMaxBelowZero = errors.New("range invalid: max below zero")
 )
+
+// warnings are errors that were auto-corrected, they
+// do not prevent generation
+var (
+	ErrReusedName       = errors.New("name already used")
+	ErrReusedAlias      = errors.New("alias already used")
+	ErrGeneratedClamped = errors.New("options not allowed on generated kind")
+	ErrOptionIgnored    = errors.New("option ignored")
+	ErrEnumReused       = errors.New("enum value already used")
+)
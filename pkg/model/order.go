@@ -0,0 +1,80 @@
+package model
+
+import "slices"
+
+// EntityOrder is entities ordered so each comes after the entities it
+// references, such that creating them in order does not reference
+// something that does not exist yet
+type EntityOrder struct {
+	Entities []*Entity
+	// Deferred are the references that would close a cycle. They
+	// are to be added once all of the entities exist.
+	Deferred []*Attribute
+}
+
+// OrderEntities sorts the entities of all schemas by what they reference,
+// across schemas, keeping declaration order where there is no dependency.
+// An entity referencing itself is not a cycle.
+func OrderEntities(schemas []*Schema) EntityOrder {
+	var (
+		order = EntityOrder{
+			Entities: make([]*Entity, 0, 10),
+			Deferred: make([]*Attribute, 0),
+		}
+		visiting = make(map[*Entity]bool)
+		visited  = make(map[*Entity]bool)
+		deferred = make(map[*AttributeRaw]bool)
+	)
+
+	var visit func(ent *Entity)
+	visit = func(ent *Entity) {
+		visiting[ent] = true
+		for _, attr := range ent.RawAttributes {
+			to := attr.ReferenceTo
+			if to == nil || to == ent || attr.HasErr() {
+				continue
+			}
+			if visiting[to] {
+				deferred[attr] = true
+				continue
+			}
+			if !visited[to] {
+				visit(to)
+			}
+		}
+		visiting[ent] = false
+		visited[ent] = true
+		order.Entities = append(order.Entities, ent)
+	}
+
+	for _, sch := range schemas {
+		for _, ent := range sch.Entities {
+			if !visited[ent] {
+				visit(ent)
+			}
+		}
+	}
+
+	for _, ent := range order.Entities {
+		for _, attr := range ent.Attributes() {
+			if deferred[attr.Source] && !attr.DirectChild {
+				order.Deferred = append(order.Deferred, attr)
+			}
+		}
+	}
+
+	return order
+}
+
+// IsDeferred is true if the reference an attribute is from is deferred
+func (order EntityOrder) IsDeferred(attr *Attribute) bool {
+	return slices.Contains(order.Deferred, attr)
+}
+
+// Reversed is the entities in the order they can be dropped in.
+// Used in templating.
+func (order EntityOrder) Reversed() []*Entity {
+	reversed := slices.Clone(order.Entities)
+	slices.Reverse(reversed)
+	return reversed
+}
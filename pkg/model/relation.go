@@ -33,9 +33,20 @@ func (rel *Relation) Name() string {
 	return strcase.ToSnake(fmt.Sprintf("%s_%s", rel.Base.Name, rel.HasName()))
 }
 
+// Self is if an entity relates to itself, as a tree does
+func (rel *Relation) Self() bool {
+	return rel.Assoc == nil && rel.Base == rel.Has
+}
+
 // HasName provides a case corrected name for the has entity use in templating
-// many-many or 0(1)-1
+// many-many or 0(1)-1. An entity related to itself has a parent and children.
 func (rel *Relation) HasName() string {
+	if rel.Self() {
+		if rel.Many {
+			return "children"
+		}
+		return "parent"
+	}
 	if rel.Assoc == nil && !rel.Many {
 		return rel.Has.Name
 	}
@@ -56,7 +67,7 @@ func (rel *Relation) PrimaryBaseToHas() []*Attribute {
 		if attr.DirectChild {
 			continue
 		}
-		a := attr.Reference.Final.Parent
+		a := attr.Referenced()
 		b := rel.Has
 		if a == b {
 			overlap = append(overlap, attr)
@@ -74,7 +85,7 @@ func (rel *Relation) PrimaryHasToBase() []*Attribute {
 		if attr.DirectChild {
 			continue
 		}
-		a := attr.Reference.Final.Parent
+		a := attr.Referenced()
 		b := rel.Base
 		if a == b {
 			overlap = append(overlap, attr)
@@ -95,7 +106,7 @@ func (rel *Relation) KeysForAssocRelation() [2][]*Attribute {
 		if attr.DirectChild {
 			continue
 		}
-		a := attr.Reference.Final.Parent
+		a := attr.Referenced()
 		b := rel.Base
 		if a == b {
 			overlap[0] = append(overlap[0], attr)
@@ -105,7 +116,7 @@ func (rel *Relation) KeysForAssocRelation() [2][]*Attribute {
 		if attr.DirectChild {
 			continue
 		}
-		a := attr.Reference.Final.Parent
+		a := attr.Referenced()
 		b := rel.Has
 		if a == b {
 			overlap[1] = append(overlap[1], attr)
@@ -137,12 +148,12 @@ func (relationMaker *RelationMaker) Determine() []Relation {
 				pks := entity.Primary()
 				for _, pkA := range pks {
 					for _, pkB := range pks {
-						if pkA.Attribute.Parent == pkB.Attribute.Parent {
+						if pkA.Referenced() == pkB.Referenced() {
 							continue
 						}
 						candidate := Relation{
-							Base:     pkA.Attribute.Parent,
-							Has:      pkB.Attribute.Parent,
+							Base:     pkA.Referenced(),
+							Has:      pkB.Referenced(),
 							Assoc:    entity,
 							Optional: true,
 						}
@@ -172,7 +183,7 @@ func (relationMaker *RelationMaker) Determine() []Relation {
 				}
 				candidate := Relation{
 					Base:     entity,
-					Has:      attr.Final.Parent,
+					Has:      attr.Referenced(),
 					Optional: !attr.Reference.Source.Required.Bool,
 					Many:     false,
 				}
@@ -198,16 +209,17 @@ func (relationMaker *RelationMaker) Determine() []Relation {
 					continue
 				}
 				candidate := Relation{
-					Base:     attr.Final.Parent,
+					Base:     attr.Referenced(),
 					Has:      entity,
 					Optional: true,
 					Many:     true,
 				}
 
-				// prevents duplicate on composite pk
+				// prevents duplicate on composite pk, while an entity
+				// related to itself has both its parent and children
 				distinct := true
 				for _, rel := range relations {
-					if rel.Base == candidate.Base && rel.Has == candidate.Has {
+					if rel.Base == candidate.Base && rel.Has == candidate.Has && (!rel.Self() || rel.Many) {
 						distinct = false
 						break
 					}
@@ -2,7 +2,9 @@ package model
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Isaac799/devtoolbox/internal"
@@ -42,6 +44,140 @@ func (sch *Schema) HasErr() bool {
 	return false
 }
 
+// HasWarn is true if anything in the schema was auto-corrected
+func (sch *Schema) HasWarn() bool {
+	for _, ent := range sch.Entities {
+		for _, attr := range ent.RawAttributes {
+			if attr.HasWarn() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasKind is true if any attribute of the schema, including those
+// flattened from references, is a single value of a kind. An array
+// is not counted, as it may be held differently. Used in templating.
+func (sch *Schema) HasKind(kinds ...AttrKind) bool {
+	for _, ent := range sch.Entities {
+		for _, attr := range ent.Attributes() {
+			if attr.Final.Array {
+				continue
+			}
+			if slices.Contains(kinds, attr.Final.Kind) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasVersioned is true if an entity of the schema is versioned, so
+// its store has a conflict to report. Used in templating.
+func (sch *Schema) HasVersioned() bool {
+	return slices.ContainsFunc(sch.Entities, func(ent *Entity) bool {
+		return ent.Versioned
+	})
+}
+
+// HasSoftDelete is true if an entity of the schema is soft deleted, so
+// its store filters what is deleted. Used in templating.
+func (sch *Schema) HasSoftDelete() bool {
+	return slices.ContainsFunc(sch.Entities, func(ent *Entity) bool {
+		return ent.SoftDelete
+	})
+}
+
+// HasArray is true if any attribute of the schema is an array.
+// Used in templating.
+func (sch *Schema) HasArray() bool {
+	for _, ent := range sch.Entities {
+		for _, attr := range ent.Attributes() {
+			if attr.Final.Array {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DefaultDepth is how many references deep keys are flattened unless set,
+// enough for the keys of what is referenced but not what those reference
+const DefaultDepth = 1
+
+// SetDepth sets how many references deep the keys of every entity are
+// flattened, zero being the default, and ensures none go deeper. It is
+// to be set again as entities change, so each is held to the same depth.
+func SetDepth(schemas []*Schema, depth int) {
+	for _, sch := range schemas {
+		for _, ent := range sch.Entities {
+			ent.depth = depth
+		}
+	}
+	ensureValidDepth(schemas)
+}
+
+// ensureValidDepth will error a reference whose keys are references deeper
+// than its entity flattens, naming the chain of what is referenced, rather
+// than some of its keys going missing
+func ensureValidDepth(schemas []*Schema) {
+	for _, sch := range schemas {
+		for _, ent := range sch.Entities {
+			ent.ClearCache()
+			for _, attr := range ent.RawAttributes {
+				attr.clearDepthErr()
+			}
+		}
+	}
+
+	for _, sch := range schemas {
+		for _, ent := range sch.Entities {
+			for _, attr := range ent.RawAttributes {
+				chain := referenceChain(attr, 0, ent.Depth(), []string{qualifiedName(ent)})
+				if chain == nil {
+					continue
+				}
+				err := fmt.Errorf("%w %d: %s", ErrDepthExceeded, ent.Depth(), strings.Join(chain, " > "))
+				attr.AppendErr(errors.Join(ErrReference, err))
+			}
+		}
+	}
+
+	for _, sch := range schemas {
+		for _, ent := range sch.Entities {
+			ent.ClearCache()
+		}
+	}
+}
+
+// referenceChain is the chain of entities referenced from an attribute
+// that goes past the max depth, or nil if it does not. Only the keys of
+// what is referenced are followed, as they are all that is flattened.
+func referenceChain(attr *AttributeRaw, n, max int, chain []string) []string {
+	if attr.ReferenceTo == nil || attr.HasErr() {
+		return nil
+	}
+	chain = append(chain, qualifiedName(attr.ReferenceTo))
+	if n >= max {
+		return chain
+	}
+	for _, a := range attr.ReferenceTo.RawAttributes {
+		if !a.Primary {
+			continue
+		}
+		if c := referenceChain(a, n+1, max, chain); c != nil {
+			return c
+		}
+	}
+	return nil
+}
+
+// qualifiedName is an entity as it is referenced from any schema
+func qualifiedName(ent *Entity) string {
+	return fmt.Sprintf("%s.%s", ent.Parent.Name, ent.Name)
+}
+
 // String provides parsable text to generate itself
 func (sch *Schema) String() string {
 	return fmt.Sprintf("# %s", sch.Name)
@@ -0,0 +1,64 @@
+// Package tmplcache parses templates once and keeps them around, so hot paths
+// do not pay to read and parse the same files on every use. A reloading
+// cache parses on every use instead, which is handy while editing templates.
+package tmplcache
+
+import (
+	"sync"
+	"text/template"
+)
+
+// Parse makes a template when it is not cached
+type Parse = func() (*template.Template, error)
+
+// Cache is a collection of parsed templates, safe for concurrent use
+type Cache struct {
+	mu     sync.RWMutex
+	reload bool
+	m      map[string]*template.Template
+}
+
+// New provides an empty cache. If reload is true templates are never
+// kept, and are parsed every time they are asked for.
+func New(reload bool) *Cache {
+	return &Cache{
+		reload: reload,
+		m:      make(map[string]*template.Template),
+	}
+}
+
+// Get provides the template for a key, using parse only if it is not
+// already cached. A clone is given so the caller may add funcs or
+// parse more into it without affecting other callers.
+func (c *Cache) Get(key string, parse Parse) (*template.Template, error) {
+	if c.reload {
+		return parse()
+	}
+
+	c.mu.RLock()
+	tmpl, ok := c.m[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		var err error
+		tmpl, err = parse()
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.m[key] = tmpl
+		c.mu.Unlock()
+	}
+
+	return tmpl.Clone()
+}
+
+// Reset forgets everything cached, so the next use of each
+// template is parsed again
+func (c *Cache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.m = make(map[string]*template.Template)
+}